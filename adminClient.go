@@ -49,4 +49,4 @@ func (c *AdminClient) DeleteConsumer(stream, group, consumer string) (int64, err
 	return c.handle.XGroupDelConsumer(stream, group, consumer).Result()
 }
 
-// TODO: it might be add commands like XINFO, XLEN, XTRIM, XPENDING, XRANGE, XREVRANGE
+// TODO: it might be add commands like XLEN
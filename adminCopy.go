@@ -0,0 +1,141 @@
+package redis
+
+import (
+	redis "github.com/go-redis/redis/v7"
+)
+
+type CopyOption struct {
+	BatchSize     int64 // 每次複製的訊息數, 預設為 DEFAULT_RANGE_PAGE_SIZE
+	ReplayPending bool  // 在目的 stream 重建 pending 訊息的 consumer 歸屬
+}
+
+type CopyResult struct {
+	Copied  int64
+	Groups  []string
+	Pending int64 // 重建的 pending 訊息數
+}
+
+// CopyStream copies src to dstStream on dst, which might be c itself or
+// another redis, preserving the entry IDs; dstStream must not have entries
+// newer than src. The consumer groups are recreated with the same
+// last-delivered-id and, with ReplayPending, their pending entries are
+// claimed to the same consumers, keeping the delivery count and idle time,
+// so in-flight work is not lost.
+func (c *AdminClient) CopyStream(src string, dst *AdminClient, dstStream string, opt *CopyOption) (*CopyResult, error) {
+	if opt == nil {
+		opt = &CopyOption{}
+	}
+	batchSize := opt.BatchSize
+	if batchSize <= 0 {
+		batchSize = DEFAULT_RANGE_PAGE_SIZE
+	}
+
+	var result = &CopyResult{}
+
+	// entries
+	var (
+		it    = c.Range(src, "-", "+", &RangeOption{PageSize: batchSize})
+		batch = make([]XMessage, 0, batchSize)
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		pipe := dst.handle.Pipeline()
+		for _, message := range batch {
+			pipe.XAdd(&redis.XAddArgs{
+				Stream: dstStream,
+				ID:     message.ID,
+				Values: message.Values,
+			})
+		}
+		_, err := pipe.Exec()
+		if err != nil {
+			return err
+		}
+		result.Copied += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+	for it.Next() {
+		batch = append(batch, *it.Message())
+		if int64(len(batch)) >= batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return result, err
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	// consumer groups
+	groups, err := c.GroupsInfo(src)
+	if err != nil {
+		return result, err
+	}
+	for _, g := range groups {
+		created, err := dst.EnsureConsumerGroup(dstStream, g.Name, g.LastDeliveredID)
+		if err != nil {
+			return result, err
+		}
+		if !created {
+			_, err = dst.SetConsumerGroupOffset(dstStream, g.Name, g.LastDeliveredID)
+			if err != nil {
+				return result, err
+			}
+		}
+		result.Groups = append(result.Groups, g.Name)
+
+		if opt.ReplayPending && g.Pending > 0 {
+			replayed, err := c.replayPending(src, g.Name, dst, dstStream)
+			if err != nil {
+				return result, err
+			}
+			result.Pending += replayed
+		}
+	}
+	return result, nil
+}
+
+func (c *AdminClient) replayPending(src, group string, dst *AdminClient, dstStream string) (int64, error) {
+	entries, err := c.ListPending(src, group, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var replayed int64
+	for i := 0; i < len(entries); i += int(DEFAULT_RANGE_PAGE_SIZE) {
+		end := i + int(DEFAULT_RANGE_PAGE_SIZE)
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		var (
+			pipe = dst.handle.Pipeline()
+			cmds = make([]*redis.Cmd, 0, end-i)
+		)
+		for _, entry := range entries[i:end] {
+			// FORCE creates the PEL entry which does not exist on dst
+			cmds = append(cmds, pipe.Do("XCLAIM", dstStream, group, entry.Consumer, 0, entry.ID,
+				"IDLE", entry.Idle.Milliseconds(),
+				"RETRYCOUNT", entry.DeliveryCount,
+				"FORCE", "JUSTID"))
+		}
+		_, err := pipe.Exec()
+		if err != nil {
+			if err != redis.Nil {
+				return replayed, err
+			}
+		}
+		for _, cmd := range cmds {
+			if ids, ok := cmd.Val().([]interface{}); ok {
+				replayed += int64(len(ids))
+			}
+		}
+	}
+	return replayed, nil
+}
@@ -0,0 +1,379 @@
+package redis
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+const exportEncodingBase64 = "base64"
+
+// ExportHeader is the first line of an exported stream.
+type ExportHeader struct {
+	Version         int              `json:"version"`
+	Stream          string           `json:"stream"`
+	Length          int64            `json:"length"`
+	LastGeneratedID string           `json:"last_generated_id,omitempty"`
+	Groups          []*ExportedGroup `json:"groups"`
+	ExportedAt      time.Time        `json:"exported_at"`
+}
+
+type ExportedGroup struct {
+	Name            string `json:"name"`
+	LastDeliveredID string `json:"last_delivered_id"`
+}
+
+// exportedEntry is a line after the header. Fields are name/value pairs;
+// if any of them is not valid UTF-8, all are base64 encoded and Encoding
+// is "base64".
+type exportedEntry struct {
+	ID       string      `json:"id"`
+	Fields   [][2]string `json:"fields"`
+	Encoding string      `json:"encoding,omitempty"`
+}
+
+type ImportOption struct {
+	Stream        string // 匯入的 stream, 預設為匯出時的 stream
+	PreserveID    bool   // 保留原訊息 ID; 目的 stream 必須沒有較新的訊息
+	RestoreGroups bool   // 依匯出時的 last-delivered-id 建立或設定 consumer group
+}
+
+type ImportResult struct {
+	Stream   string
+	Imported int64
+	Skipped  int64             // PreserveID 時已存在於目的 stream 而略過的訊息數
+	Groups   map[string]string // 還原的 group 及其 last-delivered-id
+}
+
+// ExportStream writes stream to w as JSON Lines: an ExportHeader followed
+// by one line per entry. It returns the number of exported entries.
+func (c *AdminClient) ExportStream(stream string, w io.Writer) (int64, error) {
+	info, err := c.StreamInfo(stream)
+	if err != nil {
+		return 0, err
+	}
+	groups, err := c.GroupsInfo(stream)
+	if err != nil {
+		return 0, err
+	}
+
+	header := &ExportHeader{
+		Version:         EXPORT_FORMAT_VERSION,
+		Stream:          stream,
+		Length:          info.Length,
+		LastGeneratedID: info.LastGeneratedID,
+		Groups:          make([]*ExportedGroup, 0, len(groups)),
+		ExportedAt:      time.Now().UTC(),
+	}
+	for _, g := range groups {
+		header.Groups = append(header.Groups, &ExportedGroup{
+			Name:            g.Name,
+			LastDeliveredID: g.LastDeliveredID,
+		})
+	}
+
+	var (
+		writer  = bufio.NewWriter(w)
+		encoder = json.NewEncoder(writer)
+		count   int64
+	)
+	err = encoder.Encode(header)
+	if err != nil {
+		return 0, err
+	}
+
+	it := c.Range(stream, "-", "+", nil)
+	for it.Next() {
+		entry, err := encodeExportedEntry(it.Message())
+		if err != nil {
+			return count, err
+		}
+		err = encoder.Encode(entry)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := it.Err(); err != nil {
+		return count, err
+	}
+	return count, writer.Flush()
+}
+
+// ImportStream reads a stream exported by ExportStream from r and appends
+// its entries. With PreserveID the entries which are not after the last
+// entry of the target stream are skipped, and the last-generated-id of the
+// exported stream is restored with XSETID, so new entries never reuse the
+// IDs it has issued.
+//
+// The entries are written in pipelined batches; on failure a batch might be
+// written partially. With PreserveID the import can be resumed by running
+// it again, otherwise the written entries would be duplicated.
+func (c *AdminClient) ImportStream(r io.Reader, opt *ImportOption) (*ImportResult, error) {
+	if opt == nil {
+		opt = &ImportOption{}
+	}
+
+	var (
+		decoder = json.NewDecoder(bufio.NewReader(r))
+		header  ExportHeader
+	)
+	err := decoder.Decode(&header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export header: %v", err)
+	}
+	if header.Version != EXPORT_FORMAT_VERSION {
+		return nil, fmt.Errorf("unsupported export format version %d", header.Version)
+	}
+
+	result := &ImportResult{
+		Stream: opt.Stream,
+		Groups: make(map[string]string),
+	}
+	if len(result.Stream) == 0 {
+		result.Stream = header.Stream
+	}
+
+	// the offset of a group whose entries are not imported yet
+	startID, err := lastStreamID(c.handle, result.Stream)
+	if err != nil {
+		return nil, err
+	}
+	lastID, err := internal.ParseStreamID(startID)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		offsets          = make([]string, len(header.Groups))
+		lastDeliveredIDs = make([]internal.StreamID, len(header.Groups))
+	)
+	for i, g := range header.Groups {
+		offsets[i] = startID
+		lastDeliveredIDs[i], err = internal.ParseStreamID(g.LastDeliveredID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var batch = make([]*exportedEntry, 0, DEFAULT_RANGE_PAGE_SIZE)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		var skip = make([]bool, len(batch))
+		if opt.PreserveID {
+			for i, entry := range batch {
+				id, err := internal.ParseStreamID(entry.ID)
+				if err != nil {
+					return err
+				}
+				// imported by a previous run
+				skip[i] = id.Compare(lastID) <= 0
+			}
+		}
+
+		ids, err := c.importEntries(result.Stream, batch, skip, opt.PreserveID)
+		if err != nil {
+			return err
+		}
+		for i, entry := range batch {
+			old, err := internal.ParseStreamID(entry.ID)
+			if err != nil {
+				return err
+			}
+			for j := range header.Groups {
+				if old.Compare(lastDeliveredIDs[j]) <= 0 {
+					offsets[j] = ids[i]
+				}
+			}
+			if skip[i] {
+				result.Skipped++
+			} else {
+				result.Imported++
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		var entry exportedEntry
+		err := decoder.Decode(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("invalid exported entry after %d entries: %v", result.Imported+int64(len(batch)), err)
+		}
+		batch = append(batch, &entry)
+
+		if int64(len(batch)) >= DEFAULT_RANGE_PAGE_SIZE {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	if opt.PreserveID && len(header.LastGeneratedID) > 0 {
+		err = c.restoreLastGeneratedID(result.Stream, header.LastGeneratedID)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if opt.RestoreGroups {
+		for i, g := range header.Groups {
+			created, err := c.EnsureConsumerGroup(result.Stream, g.Name, offsets[i])
+			if err != nil {
+				return result, err
+			}
+			if !created {
+				_, err = c.SetConsumerGroupOffset(result.Stream, g.Name, offsets[i])
+				if err != nil {
+					return result, err
+				}
+			}
+			result.Groups[g.Name] = offsets[i]
+		}
+	}
+	return result, nil
+}
+
+// restoreLastGeneratedID moves the last-generated-id of stream forward to
+// id; the entries after the last exported one might have been deleted.
+func (c *AdminClient) restoreLastGeneratedID(stream string, id string) error {
+	lastGeneratedID, err := internal.ParseStreamID(id)
+	if err != nil {
+		return err
+	}
+	top, err := lastStreamID(c.handle, stream)
+	if err != nil {
+		return err
+	}
+	topID, err := internal.ParseStreamID(top)
+	if err != nil {
+		return err
+	}
+	if lastGeneratedID.Compare(topID) <= 0 {
+		return nil
+	}
+
+	exists, err := c.handle.Exists(stream).Result()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		// nothing is imported; XSETID requires the stream
+		return nil
+	}
+	return c.handle.Do("XSETID", stream, id).Err()
+}
+
+func (c *AdminClient) importEntries(stream string, entries []*exportedEntry, skip []bool, preserveID bool) ([]string, error) {
+	var (
+		pipe = c.handle.Pipeline()
+		cmds = make([]*redis.StringCmd, len(entries))
+		ids  = make([]string, len(entries))
+	)
+	for i, entry := range entries {
+		if skip[i] {
+			ids[i] = entry.ID
+			continue
+		}
+		values, err := decodeExportedEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		id := StreamAsteriskID
+		if preserveID {
+			id = entry.ID
+		}
+		cmds[i] = pipe.XAdd(&redis.XAddArgs{
+			Stream: stream,
+			ID:     id,
+			Values: values,
+		})
+	}
+	_, err := pipe.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		if cmd != nil {
+			ids[i] = cmd.Val()
+		}
+	}
+	return ids, nil
+}
+
+func encodeExportedEntry(message *XMessage) (*exportedEntry, error) {
+	fields, err := internal.NormalizeValues(message.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		entry = &exportedEntry{
+			ID:     message.ID,
+			Fields: make([][2]string, 0, len(fields)),
+		}
+		binary bool
+	)
+	for _, name := range internal.SortedFieldNames(fields) {
+		value := fields[name]
+		if !utf8.ValidString(name) || !utf8.ValidString(value) {
+			binary = true
+		}
+		entry.Fields = append(entry.Fields, [2]string{name, value})
+	}
+
+	if binary {
+		entry.Encoding = exportEncodingBase64
+		for i, field := range entry.Fields {
+			entry.Fields[i] = [2]string{
+				base64.StdEncoding.EncodeToString([]byte(field[0])),
+				base64.StdEncoding.EncodeToString([]byte(field[1])),
+			}
+		}
+	}
+	return entry, nil
+}
+
+func decodeExportedEntry(entry *exportedEntry) (map[string]interface{}, error) {
+	var values = make(map[string]interface{}, len(entry.Fields))
+	for _, field := range entry.Fields {
+		name, value := field[0], field[1]
+
+		switch entry.Encoding {
+		case "":
+		case exportEncodingBase64:
+			n, err := base64.StdEncoding.DecodeString(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field of entry %s: %v", entry.ID, err)
+			}
+			v, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field of entry %s: %v", entry.ID, err)
+			}
+			name, value = string(n), string(v)
+		default:
+			return nil, fmt.Errorf("unsupported encoding %q of entry %s", entry.Encoding, entry.ID)
+		}
+		values[name] = value
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("entry %s has no field", entry.ID)
+	}
+	return values, nil
+}
@@ -0,0 +1,282 @@
+package redis
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+)
+
+// StreamInfo is the reply of XINFO STREAM. Fields which are not reported
+// by the server, e.g. EntriesAdded before redis 7.0, are -1 or empty.
+type StreamInfo struct {
+	Length               int64
+	RadixTreeKeys        int64
+	RadixTreeNodes       int64
+	Groups               int64
+	LastGeneratedID      string
+	MaxDeletedEntryID    string // redis 7.0+
+	EntriesAdded         int64  // redis 7.0+
+	RecordedFirstEntryID string // redis 7.0+
+	FirstEntry           *XMessage
+	LastEntry            *XMessage
+}
+
+// GroupInfo is the reply of XINFO GROUPS. EntriesRead and Lag are -1 if
+// the server does not report them or cannot compute them.
+type GroupInfo struct {
+	Name            string
+	Consumers       int64
+	Pending         int64
+	LastDeliveredID string
+	EntriesRead     int64 // redis 7.0+
+	Lag             int64 // redis 7.0+
+}
+
+// ConsumerInfo is the reply of XINFO CONSUMERS. Inactive is -1 if the
+// server does not report it.
+type ConsumerInfo struct {
+	Name     string
+	Pending  int64
+	Idle     time.Duration
+	Inactive time.Duration // redis 7.2+
+}
+
+func (c *AdminClient) StreamInfo(stream string) (*StreamInfo, error) {
+	reply, err := c.handle.Do("XINFO", "STREAM", stream).Result()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := parseInfoReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &StreamInfo{
+		Length:               fields.int("length", 0),
+		RadixTreeKeys:        fields.int("radix-tree-keys", -1),
+		RadixTreeNodes:       fields.int("radix-tree-nodes", -1),
+		Groups:               fields.int("groups", -1),
+		LastGeneratedID:      fields.string("last-generated-id"),
+		MaxDeletedEntryID:    fields.string("max-deleted-entry-id"),
+		EntriesAdded:         fields.int("entries-added", -1),
+		RecordedFirstEntryID: fields.string("recorded-first-entry-id"),
+	}
+	info.FirstEntry, err = parseInfoEntry(fields["first-entry"])
+	if err != nil {
+		return nil, err
+	}
+	info.LastEntry, err = parseInfoEntry(fields["last-entry"])
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (c *AdminClient) GroupsInfo(stream string) ([]*GroupInfo, error) {
+	reply, err := c.handle.Do("XINFO", "GROUPS", stream).Result()
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected XINFO GROUPS reply %T", reply)
+	}
+
+	var result = make([]*GroupInfo, 0, len(items))
+	for _, item := range items {
+		fields, err := parseInfoReply(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &GroupInfo{
+			Name:            fields.string("name"),
+			Consumers:       fields.int("consumers", 0),
+			Pending:         fields.int("pending", 0),
+			LastDeliveredID: fields.string("last-delivered-id"),
+			EntriesRead:     fields.int("entries-read", -1),
+			Lag:             fields.int("lag", -1),
+		})
+	}
+	return result, nil
+}
+
+// GroupInfo returns the XINFO GROUPS entry of group, or nil if the group
+// does not exist.
+func (c *AdminClient) GroupInfo(stream, group string) (*GroupInfo, error) {
+	groups, err := c.GroupsInfo(stream)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if g.Name == group {
+			return g, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *AdminClient) ConsumersInfo(stream, group string) ([]*ConsumerInfo, error) {
+	reply, err := c.handle.Do("XINFO", "CONSUMERS", stream, group).Result()
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected XINFO CONSUMERS reply %T", reply)
+	}
+
+	var result = make([]*ConsumerInfo, 0, len(items))
+	for _, item := range items {
+		fields, err := parseInfoReply(item)
+		if err != nil {
+			return nil, err
+		}
+		info := &ConsumerInfo{
+			Name:     fields.string("name"),
+			Pending:  fields.int("pending", 0),
+			Idle:     time.Duration(fields.int("idle", 0)) * time.Millisecond,
+			Inactive: -1,
+		}
+		if inactive := fields.int("inactive", -1); inactive >= 0 {
+			info.Inactive = time.Duration(inactive) * time.Millisecond
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+type infoFields map[string]interface{}
+
+func (f infoFields) int(name string, defaultValue int64) int64 {
+	if v, ok := f[name].(int64); ok {
+		return v
+	}
+	return defaultValue
+}
+
+func (f infoFields) string(name string) string {
+	switch v := f[name].(type) {
+	case string:
+		return v
+	case int64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// parseInfoReply converts the flat name/value array of XINFO to a map.
+func parseInfoReply(reply interface{}) (infoFields, error) {
+	items, ok := reply.([]interface{})
+	if !ok || len(items)%2 != 0 {
+		return nil, fmt.Errorf("unexpected XINFO reply %v", reply)
+	}
+
+	var fields = make(infoFields, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		name, ok := items[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected XINFO field name %v", items[i])
+		}
+		fields[name] = items[i+1]
+	}
+	return fields, nil
+}
+
+func parseInfoEntry(reply interface{}) (*XMessage, error) {
+	if reply == nil {
+		return nil, nil
+	}
+	items, ok := reply.([]interface{})
+	if !ok || len(items) != 2 {
+		return nil, fmt.Errorf("unexpected XINFO entry %v", reply)
+	}
+	id, _ := items[0].(string)
+	values, _ := items[1].([]interface{})
+
+	message := &XMessage{
+		ID:     id,
+		Values: make(map[string]interface{}, len(values)/2),
+	}
+	for i := 0; i+1 < len(values); i += 2 {
+		name, _ := values[i].(string)
+		message.Values[name] = values[i+1]
+	}
+	return message, nil
+}
+
+// ListStreams returns the stream keys matching pattern, e.g. "orders:*".
+// On redis cluster every master node is scanned.
+func (c *AdminClient) ListStreams(pattern string) ([]string, error) {
+	if len(pattern) == 0 {
+		pattern = "*"
+	}
+
+	if cluster, ok := c.handle.(*redis.ClusterClient); ok {
+		var (
+			result []string
+			mutex  sync.Mutex
+		)
+		err := cluster.ForEachMaster(func(client *redis.Client) error {
+			streams, err := scanStreams(client, pattern)
+			if err != nil {
+				return err
+			}
+			mutex.Lock()
+			result = append(result, streams...)
+			mutex.Unlock()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(result)
+		return result, nil
+	}
+
+	result, err := scanStreams(c.handle, pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// scanStreams scans the keys matching pattern and keeps the streams; SCAN
+// TYPE is not used since it requires redis 6.0.
+func scanStreams(client redis.Cmdable, pattern string) ([]string, error) {
+	var (
+		result []string
+		cursor uint64
+	)
+	for {
+		keys, next, err := client.Scan(cursor, pattern, DEFAULT_RANGE_PAGE_SIZE).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(keys) > 0 {
+			pipe := client.Pipeline()
+			cmds := make([]*redis.StatusCmd, 0, len(keys))
+			for _, key := range keys {
+				cmds = append(cmds, pipe.Type(key))
+			}
+			_, err = pipe.Exec()
+			if err != nil {
+				return nil, err
+			}
+			for i, cmd := range cmds {
+				if cmd.Val() == "stream" {
+					result = append(result, keys[i])
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+type GroupLag struct {
+	Stream               string
+	Group                string
+	LastDeliveredID      string
+	Lag                  int64         // 尚未傳遞給 group 的訊息數
+	Pending              int64         // 已傳遞但尚未 Ack 的訊息數
+	OldestPendingAge     time.Duration // 最早一筆 pending 訊息的時間, 沒有時為 0
+	OldestUndeliveredAge time.Duration // 最早一筆尚未傳遞訊息的時間, 沒有時為 0
+}
+
+// GroupLag reports how far group is behind on stream. The lag comes from
+// XINFO GROUPS on redis 7.0+; on older servers, or when redis cannot tell,
+// e.g. with tombstones after the last-delivered-id or an unknown
+// entries-read after SETID, the undelivered entries are counted with XRANGE.
+func (c *AdminClient) GroupLag(stream, group string) (*GroupLag, error) {
+	info, err := c.GroupInfo(stream, group)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("no such consumer group %s on %s", group, stream)
+	}
+
+	lag := &GroupLag{
+		Stream:          stream,
+		Group:           group,
+		LastDeliveredID: info.LastDeliveredID,
+		Pending:         info.Pending,
+	}
+
+	lastDeliveredID, err := internal.ParseStreamID(info.LastDeliveredID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the lag estimated without entries-read is not reliable on every server
+	if info.Lag >= 0 && info.EntriesRead >= 0 {
+		lag.Lag = info.Lag
+	} else {
+		lag.Lag, err = c.countRange(stream, lastDeliveredID.Next().String(), "+")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var now = time.Now()
+	if info.Pending > 0 {
+		pending, err := c.handle.XPending(stream, group).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return nil, err
+			}
+		}
+		if pending != nil {
+			if id, err := internal.ParseStreamID(pending.Lower); err == nil {
+				lag.OldestPendingAge = ageOf(now, id)
+			}
+		}
+	}
+
+	undelivered, err := c.handle.XRangeN(stream, lastDeliveredID.Next().String(), "+", 1).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return nil, err
+		}
+	}
+	if len(undelivered) > 0 {
+		if id, err := internal.ParseStreamID(undelivered[0].ID); err == nil {
+			lag.OldestUndeliveredAge = ageOf(now, id)
+		}
+	}
+	return lag, nil
+}
+
+// GroupLags reports the lag of group on each of streams.
+func (c *AdminClient) GroupLags(group string, streams ...string) ([]*GroupLag, error) {
+	var result = make([]*GroupLag, 0, len(streams))
+	for _, stream := range streams {
+		lag, err := c.GroupLag(stream, group)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, lag)
+	}
+	return result, nil
+}
+
+// countRange counts the entries between start and end inclusively, page by
+// page.
+func (c *AdminClient) countRange(stream, start, end string) (int64, error) {
+	var count int64
+	for {
+		messages, err := c.handle.XRangeN(stream, start, end, DEFAULT_RANGE_PAGE_SIZE).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return 0, err
+			}
+		}
+		count += int64(len(messages))
+		if int64(len(messages)) < DEFAULT_RANGE_PAGE_SIZE {
+			return count, nil
+		}
+
+		last, err := internal.ParseStreamID(messages[len(messages)-1].ID)
+		if err != nil {
+			return 0, err
+		}
+		start = last.Next().String()
+	}
+}
+
+func ageOf(now time.Time, id internal.StreamID) time.Duration {
+	age := now.Sub(id.Time())
+	if age < 0 {
+		return 0
+	}
+	return age
+}
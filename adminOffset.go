@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+// GroupOffset is the target of ResetConsumerGroupOffset; create it with
+// OffsetID, OffsetAtTime, OffsetAgo, OffsetBeginning, OffsetEnd or
+// OffsetBack.
+type GroupOffset struct {
+	id          string
+	time        time.Time
+	ago         time.Duration
+	back        int64
+	kind        int
+	description string
+}
+
+const (
+	offsetKindID = iota
+	offsetKindTime
+	offsetKindAgo
+	offsetKindBeginning
+	offsetKindEnd
+	offsetKindBack
+)
+
+// OffsetID resets the group to deliver the entries after id.
+func OffsetID(id string) GroupOffset {
+	return GroupOffset{kind: offsetKindID, id: id, description: id}
+}
+
+// OffsetAtTime resets the group to deliver the entries added at t or later.
+func OffsetAtTime(t time.Time) GroupOffset {
+	return GroupOffset{kind: offsetKindTime, time: t, description: t.Format(time.RFC3339Nano)}
+}
+
+// OffsetAgo resets the group to deliver the entries added in the last d.
+func OffsetAgo(d time.Duration) GroupOffset {
+	return GroupOffset{kind: offsetKindAgo, ago: d, description: d.String() + " ago"}
+}
+
+// OffsetBeginning resets the group to deliver all entries.
+func OffsetBeginning() GroupOffset {
+	return GroupOffset{kind: offsetKindBeginning, description: "beginning"}
+}
+
+// OffsetEnd resets the group to deliver the entries added from now on.
+func OffsetEnd() GroupOffset {
+	return GroupOffset{kind: offsetKindEnd, description: "end"}
+}
+
+// OffsetBack resets the group to deliver the last n entries.
+func OffsetBack(n int64) GroupOffset {
+	return GroupOffset{kind: offsetKindBack, back: n, description: fmt.Sprintf("%d entries back", n)}
+}
+
+func (o GroupOffset) String() string {
+	return o.description
+}
+
+type OffsetResetResult struct {
+	Stream      string
+	Group       string
+	PreviousID  string // 重設前的 last-delivered-id
+	ID          string // 重設後的 last-delivered-id
+	Redelivered int64  // 會被重新傳遞的訊息數
+	Skipped     int64  // 會被略過不傳遞的訊息數
+	Pending     int64  // 仍在 PEL 中的訊息數; 重設不影響這些訊息
+	Warning     string
+	DryRun      bool
+}
+
+// ResetConsumerGroupOffset moves the last-delivered-id of group on stream
+// to offset. With dryRun, nothing is changed but the result is reported.
+//
+// Entries already in the PEL are not affected; they remain pending and
+// might be claimed, and thus delivered, again.
+func (c *AdminClient) ResetConsumerGroupOffset(stream, group string, offset GroupOffset, dryRun bool) (*OffsetResetResult, error) {
+	info, err := c.GroupInfo(stream, group)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("no such consumer group %s on %s", group, stream)
+	}
+
+	id, err := c.resolveGroupOffset(stream, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OffsetResetResult{
+		Stream:     stream,
+		Group:      group,
+		PreviousID: info.LastDeliveredID,
+		ID:         id.String(),
+		Pending:    info.Pending,
+		DryRun:     dryRun,
+	}
+
+	previous, err := internal.ParseStreamID(info.LastDeliveredID)
+	if err != nil {
+		return nil, err
+	}
+	switch id.Compare(previous) {
+	case -1:
+		result.Redelivered, err = c.countRange(stream, id.Next().String(), previous.String())
+	case 1:
+		result.Skipped, err = c.countRange(stream, previous.Next().String(), id.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Pending > 0 {
+		result.Warning = fmt.Sprintf("%d entries remain in the PEL of group %s; "+
+			"they are not affected by the reset and might be claimed and delivered again", info.Pending, group)
+	}
+
+	if !dryRun {
+		_, err = c.SetConsumerGroupOffset(stream, group, result.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (c *AdminClient) resolveGroupOffset(stream string, offset GroupOffset) (internal.StreamID, error) {
+	var zero internal.StreamID
+
+	switch offset.kind {
+	case offsetKindID:
+		return internal.ParseStreamID(offset.id)
+
+	case offsetKindTime, offsetKindAgo:
+		t := offset.time
+		if offset.kind == offsetKindAgo {
+			t = time.Now().Add(-offset.ago)
+		}
+		id := internal.StreamIDFromTime(t)
+		if id.Timestamp <= 0 {
+			return zero, nil
+		}
+		return id.Prev(), nil
+
+	case offsetKindBeginning:
+		return zero, nil
+
+	case offsetKindEnd:
+		id, err := lastStreamID(c.handle, stream)
+		if err != nil {
+			return zero, err
+		}
+		return internal.ParseStreamID(id)
+
+	case offsetKindBack:
+		if offset.back < 0 {
+			return zero, fmt.Errorf("invalid offset %s", offset)
+		}
+		messages, err := c.handle.XRevRangeN(stream, "+", "-", offset.back+1).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return zero, err
+			}
+		}
+		if int64(len(messages)) <= offset.back {
+			return zero, nil
+		}
+		return internal.ParseStreamID(messages[offset.back].ID)
+	}
+	return zero, fmt.Errorf("invalid offset %s", offset)
+}
@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+type PendingFilter struct {
+	Consumer         string        // 只列出指定 consumer 的訊息
+	MinIdle          time.Duration // 只列出閒置超過指定時間的訊息
+	MinDeliveryCount int64         // 只列出傳遞次數不少於指定次數的訊息
+	Start            string        // 起始 ID (包含), 預設為 "-"
+	End              string        // 結束 ID (包含), 預設為 "+"
+	Limit            int64         // 最多列出的訊息數, 0 表示不限制
+}
+
+type PendingEntry struct {
+	ID            string
+	Consumer      string
+	Idle          time.Duration
+	DeliveryCount int64
+	Message       *XMessage // 只在 ListPendingMessages 提供; 訊息已被刪除時為 nil
+}
+
+// ListPending lists the pending entries of group on stream which match
+// filter. The entries are walked page by page with XPENDING, and the idle
+// and delivery count filters are applied on the client.
+func (c *AdminClient) ListPending(stream, group string, filter *PendingFilter) ([]*PendingEntry, error) {
+	if filter == nil {
+		filter = &PendingFilter{}
+	}
+
+	var (
+		start  = filter.Start
+		end    = filter.End
+		result []*PendingEntry
+	)
+	if len(start) == 0 {
+		start = "-"
+	}
+	if len(end) == 0 {
+		end = "+"
+	}
+
+	for {
+		entries, err := c.handle.XPendingExt(&redis.XPendingExtArgs{
+			Stream:   stream,
+			Group:    group,
+			Start:    start,
+			End:      end,
+			Count:    DEFAULT_RANGE_PAGE_SIZE,
+			Consumer: filter.Consumer,
+		}).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return nil, err
+			}
+		}
+
+		for _, entry := range entries {
+			if entry.Idle < filter.MinIdle || entry.RetryCount < filter.MinDeliveryCount {
+				continue
+			}
+			result = append(result, &PendingEntry{
+				ID:            entry.ID,
+				Consumer:      entry.Consumer,
+				Idle:          entry.Idle,
+				DeliveryCount: entry.RetryCount,
+			})
+			if filter.Limit > 0 && int64(len(result)) >= filter.Limit {
+				return result, nil
+			}
+		}
+
+		if int64(len(entries)) < DEFAULT_RANGE_PAGE_SIZE {
+			return result, nil
+		}
+		last, err := internal.ParseStreamID(entries[len(entries)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+		start = last.Next().String()
+	}
+}
+
+// ListPendingMessages is ListPending with the message bodies.
+func (c *AdminClient) ListPendingMessages(stream, group string, filter *PendingFilter) ([]*PendingEntry, error) {
+	entries, err := c.ListPending(stream, group, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	var (
+		pipe = c.handle.Pipeline()
+		cmds = make([]*redis.XMessageSliceCmd, 0, len(entries))
+	)
+	for _, entry := range entries {
+		cmds = append(cmds, pipe.XRangeN(stream, entry.ID, entry.ID, 1))
+	}
+	_, err = pipe.Exec()
+	if err != nil {
+		if err != redis.Nil {
+			return nil, err
+		}
+	}
+
+	for i, cmd := range cmds {
+		messages, err := cmd.Result()
+		if err != nil {
+			if err != redis.Nil {
+				return nil, err
+			}
+		}
+		if len(messages) > 0 {
+			entries[i].Message = &messages[0]
+		}
+	}
+	return entries, nil
+}
+
+// ReassignPending moves the pending entries ids which are idle for at
+// least minIdle to consumer with XCLAIM. It returns the IDs reassigned;
+// entries which are not pending anymore, or whose messages have been
+// deleted, are skipped.
+func (c *AdminClient) ReassignPending(stream, group, consumer string, minIdle time.Duration, ids ...string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	reply, err := c.handle.XClaimJustID(&redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+// AckPending acknowledges the pending entries ids without handling them,
+// and returns the number of entries removed from the PEL.
+func (c *AdminClient) AckPending(stream, group string, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return c.handle.XAck(stream, group, ids...).Result()
+}
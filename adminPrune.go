@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"time"
+)
+
+type PrunedConsumer struct {
+	Name    string
+	Idle    time.Duration
+	Claimed int64 // 移交給 heir 的 pending 訊息數
+	Deleted bool  // 未刪除表示仍有 pending 訊息
+}
+
+// PruneConsumers deletes the consumers of group on stream which are idle
+// for at least idleThreshold. Their pending entries are claimed to heir
+// first, so nothing is dropped as DeleteConsumer does; consumers still
+// holding pending entries, e.g. when heir is empty, are kept.
+func (c *AdminClient) PruneConsumers(stream, group string, idleThreshold time.Duration, heir string) ([]*PrunedConsumer, error) {
+	consumers, err := c.ConsumersInfo(stream, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*PrunedConsumer
+	for _, consumer := range consumers {
+		if consumer.Name == heir || consumer.Idle < idleThreshold {
+			continue
+		}
+
+		pruned := &PrunedConsumer{
+			Name: consumer.Name,
+			Idle: consumer.Idle,
+		}
+		result = append(result, pruned)
+
+		if consumer.Pending > 0 {
+			if len(heir) == 0 {
+				continue
+			}
+			pruned.Claimed, err = c.claimAllPending(stream, group, consumer.Name, heir)
+			if err != nil {
+				return result, err
+			}
+
+			// the consumer might have been handling messages again
+			remaining, err := c.ListPending(stream, group, &PendingFilter{Consumer: consumer.Name, Limit: 1})
+			if err != nil {
+				return result, err
+			}
+			if len(remaining) > 0 {
+				continue
+			}
+		}
+
+		_, err = c.DeleteConsumer(stream, group, consumer.Name)
+		if err != nil {
+			return result, err
+		}
+		pruned.Deleted = true
+	}
+	return result, nil
+}
+
+func (c *AdminClient) claimAllPending(stream, group, consumer, heir string) (int64, error) {
+	entries, err := c.ListPending(stream, group, &PendingFilter{Consumer: consumer})
+	if err != nil {
+		return 0, err
+	}
+
+	var claimed int64
+	for i := 0; i < len(entries); i += int(DEFAULT_RANGE_PAGE_SIZE) {
+		end := i + int(DEFAULT_RANGE_PAGE_SIZE)
+		if end > len(entries) {
+			end = len(entries)
+		}
+		ids := make([]string, 0, end-i)
+		for _, entry := range entries[i:end] {
+			ids = append(ids, entry.ID)
+		}
+
+		reply, err := c.ReassignPending(stream, group, heir, 0, ids...)
+		if err != nil {
+			return claimed, err
+		}
+		claimed += int64(len(reply))
+	}
+	return claimed, nil
+}
@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+type RangeOption struct {
+	Reverse  bool      // 由新到舊
+	PageSize int64     // 每次 XRANGE 取得的訊息數, 預設為 DEFAULT_RANGE_PAGE_SIZE
+	Since    time.Time // 指定時取代 start, 包含該時間
+	Until    time.Time // 指定時取代 end, 包含該時間
+	Cursor   string    // 由 RangeIterator.Cursor() 繼續, 不包含 Cursor 本身
+}
+
+// RangeIterator walks a stream page by page with XRANGE or XREVRANGE.
+//
+//	it := admin.Range("orders", "-", "+", nil)
+//	for it.Next() {
+//		message := it.Message()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RangeIterator struct {
+	handle   UniversalClient
+	stream   string
+	start    string
+	end      string
+	reverse  bool
+	pageSize int64
+
+	page    []XMessage
+	index   int
+	message *XMessage
+	cursor  string
+	done    bool
+	err     error
+}
+
+// Range iterates the entries of stream between start and end inclusively.
+// start and end are IDs, "-" or "+", and are always given in ascending
+// order, also with RangeOption.Reverse.
+func (c *AdminClient) Range(stream, start, end string, opt *RangeOption) *RangeIterator {
+	if opt == nil {
+		opt = &RangeOption{}
+	}
+
+	it := &RangeIterator{
+		handle:   c.handle,
+		stream:   stream,
+		start:    start,
+		end:      end,
+		reverse:  opt.Reverse,
+		pageSize: opt.PageSize,
+	}
+	if it.pageSize <= 0 {
+		it.pageSize = DEFAULT_RANGE_PAGE_SIZE
+	}
+	if !opt.Since.IsZero() {
+		it.start = internal.StreamIDFromTime(opt.Since).String()
+	}
+	if !opt.Until.IsZero() {
+		it.end = internal.StreamID{
+			Timestamp: internal.StreamIDFromTime(opt.Until).Timestamp,
+			Sequence:  ^uint64(0),
+		}.String()
+	}
+	if len(opt.Cursor) > 0 {
+		it.cursor = opt.Cursor
+		it.err = it.advance(opt.Cursor)
+	}
+	return it
+}
+
+// Next moves to the next entry. It returns false when the range is
+// exhausted or an error occurs; see Err.
+func (it *RangeIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index >= len(it.page) {
+		if it.done {
+			it.message = nil
+			return false
+		}
+		if !it.fetch() {
+			it.message = nil
+			return false
+		}
+	}
+
+	it.message = &it.page[it.index]
+	it.cursor = it.message.ID
+	it.index++
+	return true
+}
+
+func (it *RangeIterator) Message() *XMessage {
+	return it.message
+}
+
+func (it *RangeIterator) Err() error {
+	return it.err
+}
+
+// Cursor returns the ID of the last returned entry. Passing it as
+// RangeOption.Cursor resumes the iteration after that entry.
+func (it *RangeIterator) Cursor() string {
+	return it.cursor
+}
+
+func (it *RangeIterator) fetch() bool {
+	var (
+		messages []XMessage
+		err      error
+	)
+	if it.reverse {
+		messages, err = it.handle.XRevRangeN(it.stream, it.end, it.start, it.pageSize).Result()
+	} else {
+		messages, err = it.handle.XRangeN(it.stream, it.start, it.end, it.pageSize).Result()
+	}
+	if err != nil {
+		if err != redis.Nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.page, it.index = messages, 0
+	if int64(len(messages)) < it.pageSize {
+		it.done = true
+	}
+	if len(messages) == 0 {
+		return false
+	}
+
+	// the following page excludes the last entry
+	it.err = it.advance(messages[len(messages)-1].ID)
+	return it.err == nil
+}
+
+// advance moves the bound of the range past id.
+func (it *RangeIterator) advance(id string) error {
+	last, err := internal.ParseStreamID(id)
+	if err != nil {
+		return err
+	}
+	if it.reverse {
+		if last.Timestamp == 0 && last.Sequence == 0 {
+			it.done = true
+			return nil
+		}
+		it.end = last.Prev().String()
+	} else {
+		it.start = last.Next().String()
+	}
+	return nil
+}
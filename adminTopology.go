@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+)
+
+type TopologySpec struct {
+	Streams     []*StreamSpec
+	PruneGroups bool // 刪除 spec 中沒有的 consumer group
+}
+
+type StreamSpec struct {
+	Name      string
+	Groups    []*GroupSpec
+	Retention *RetentionSpec // 保留設定, 可不指定
+}
+
+type GroupSpec struct {
+	Name    string
+	StartID string // 建立 group 時的起始 ID, 預設為 "$"
+}
+
+type RetentionSpec struct {
+	MaxLen int64         // 最多保留的訊息數, 0 表示不限制
+	MaxAge time.Duration // 最多保留的時間, 0 表示不限制
+}
+
+type TopologyReport struct {
+	CreatedStreams []string
+	CreatedGroups  []string // stream/group
+	PrunedGroups   []string // stream/group
+	Differences    []string // 與 spec 不符但未處理的項目
+}
+
+// EnsureConsumerGroup creates group on stream, and the stream itself if it
+// does not exist. It reports whether the group is created; an existing
+// group is not an error.
+func (c *AdminClient) EnsureConsumerGroup(stream, group, offset string) (bool, error) {
+	_, err := c.CreateConsumerGroupAndStream(stream, group, offset)
+	if err != nil {
+		if isBusyGroupError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ApplyTopology creates the streams and consumer groups of spec which are
+// missing, and reports what differs from spec. Groups not in spec are
+// deleted if spec.PruneGroups is set, otherwise reported. Retention is
+// only checked here; see RetentionRunner to enforce it.
+func (c *AdminClient) ApplyTopology(spec *TopologySpec) (*TopologyReport, error) {
+	var report = &TopologyReport{}
+
+	for _, s := range spec.Streams {
+		if len(s.Name) == 0 {
+			return report, fmt.Errorf("the stream name is not specified")
+		}
+
+		kind, err := c.handle.Type(s.Name).Result()
+		if err != nil {
+			return report, err
+		}
+		switch kind {
+		case "stream":
+		case "none":
+			err = c.createStream(s.Name)
+			if err != nil {
+				return report, err
+			}
+			report.CreatedStreams = append(report.CreatedStreams, s.Name)
+		default:
+			report.Differences = append(report.Differences,
+				fmt.Sprintf("%s is a %s, not a stream", s.Name, kind))
+			continue
+		}
+
+		existing, err := c.GroupsInfo(s.Name)
+		if err != nil {
+			return report, err
+		}
+		var known = make(map[string]bool, len(s.Groups))
+		for _, g := range s.Groups {
+			known[g.Name] = true
+
+			startID := g.StartID
+			if len(startID) == 0 {
+				startID = StreamLastDeliveredID
+			}
+			created, err := c.EnsureConsumerGroup(s.Name, g.Name, startID)
+			if err != nil {
+				return report, err
+			}
+			if created {
+				report.CreatedGroups = append(report.CreatedGroups, s.Name+"/"+g.Name)
+			}
+		}
+
+		for _, g := range existing {
+			if known[g.Name] {
+				continue
+			}
+			if spec.PruneGroups {
+				_, err = c.DeleteConsumerGroup(s.Name, g.Name)
+				if err != nil {
+					return report, err
+				}
+				report.PrunedGroups = append(report.PrunedGroups, s.Name+"/"+g.Name)
+			} else {
+				report.Differences = append(report.Differences,
+					fmt.Sprintf("group %s on %s is not in the spec", g.Name, s.Name))
+			}
+		}
+
+		if s.Retention != nil {
+			differences, err := c.checkRetention(s.Name, s.Retention)
+			if err != nil {
+				return report, err
+			}
+			report.Differences = append(report.Differences, differences...)
+		}
+	}
+	return report, nil
+}
+
+// createStream creates an empty stream; redis has no command for it, so
+// a temporary consumer group is created with MKSTREAM and then destroyed.
+func (c *AdminClient) createStream(stream string) error {
+	const group = RESERVED_FIELD_PREFIX + "topology"
+
+	_, err := c.CreateConsumerGroupAndStream(stream, group, StreamLastDeliveredID)
+	if err != nil {
+		if !isBusyGroupError(err) {
+			return err
+		}
+	}
+	_, err = c.DeleteConsumerGroup(stream, group)
+	return err
+}
+
+func (c *AdminClient) checkRetention(stream string, retention *RetentionSpec) ([]string, error) {
+	var differences []string
+
+	if retention.MaxLen > 0 {
+		length, err := c.handle.XLen(stream).Result()
+		if err != nil {
+			return nil, err
+		}
+		if length > retention.MaxLen {
+			differences = append(differences,
+				fmt.Sprintf("%s has %d entries, exceeding MaxLen %d", stream, length, retention.MaxLen))
+		}
+	}
+
+	if retention.MaxAge > 0 {
+		messages, err := c.handle.XRangeN(stream, "-", "+", 1).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) > 0 {
+			id, err := internal.ParseStreamID(messages[0].ID)
+			if err != nil {
+				return nil, err
+			}
+			if age := time.Since(id.Time()); age > retention.MaxAge {
+				differences = append(differences,
+					fmt.Sprintf("%s has entries of %v ago, exceeding MaxAge %v", stream, age.Truncate(time.Second), retention.MaxAge))
+			}
+		}
+	}
+	return differences, nil
+}
+
+func isBusyGroupError(err error) bool {
+	return strings.HasPrefix(err.Error(), "BUSYGROUP")
+}
@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+)
+
+var (
+	ErrBlobNotFound = errors.New("blob not found")
+)
+
+var _ BlobStore = new(RedisBlobStore)
+var _ BlobStore = new(FileBlobStore)
+
+// BlobStore keeps the message content moved out of the stream by the
+// claim-check option of the Producer.
+type BlobStore interface {
+	Put(key string, data []byte, ttl time.Duration) error
+	Get(key string) ([]byte, error)
+	Delete(keys ...string) error
+}
+
+// RedisBlobStore stores blobs as plain redis string keys.
+type RedisBlobStore struct {
+	handle redis.UniversalClient
+}
+
+func NewRedisBlobStore(client UniversalClient) *RedisBlobStore {
+	return &RedisBlobStore{
+		handle: client,
+	}
+}
+
+func (s *RedisBlobStore) Put(key string, data []byte, ttl time.Duration) error {
+	return s.handle.Set(key, data, ttl).Err()
+}
+
+func (s *RedisBlobStore) Get(key string) ([]byte, error) {
+	reply, err := s.handle.Get(key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (s *RedisBlobStore) Delete(keys ...string) error {
+	// delete keys one by one, they might be located in different cluster slots
+	for _, key := range keys {
+		err := s.handle.Del(key).Err()
+		if err != nil {
+			if err != redis.Nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FileBlobStore stores blobs as files under Dir. The expiration time is
+// written in front of the blob content; expired blobs are removed when they
+// are read or by Sweep.
+type FileBlobStore struct {
+	Dir string
+}
+
+func (s *FileBlobStore) Put(key string, data []byte, ttl time.Duration) error {
+	var expireAt int64 = 0
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl).UnixNano()
+	}
+
+	buf := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(buf, uint64(expireAt))
+	copy(buf[8:], data)
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so readers never see partial content
+	path := s.path(key)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *FileBlobStore) Get(key string) ([]byte, error) {
+	path := s.path(key)
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	if len(buf) < 8 {
+		return nil, errors.New("malformed blob file " + path)
+	}
+
+	if isBlobExpired(buf, time.Now()) {
+		os.Remove(path)
+		return nil, ErrBlobNotFound
+	}
+	return buf[8:], nil
+}
+
+func (s *FileBlobStore) Delete(keys ...string) error {
+	for _, key := range keys {
+		err := os.Remove(s.path(key))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sweep removes all expired blobs.
+func (s *FileBlobStore) Sweep() error {
+	files, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".blob" {
+			continue
+		}
+
+		path := filepath.Join(s.Dir, file.Name())
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if len(buf) >= 8 && isBlobExpired(buf, now) {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+func (s *FileBlobStore) path(key string) string {
+	return filepath.Join(s.Dir, base64.RawURLEncoding.EncodeToString([]byte(key))+".blob")
+}
+
+func isBlobExpired(buf []byte, now time.Time) bool {
+	expireAt := int64(binary.BigEndian.Uint64(buf))
+	return expireAt > 0 && now.UnixNano() >= expireAt
+}
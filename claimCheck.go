@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+type ClaimCheckOption struct {
+	Store     BlobStore     // 預設使用 Producer 的 redis
+	Threshold int           // 訊息內容的大小 (bytes) 大於等於 n 時改存到 Store, 訊息只保留參照
+	TTL       time.Duration // Store 中內容的存活時間, 0 表示不過期
+}
+
+func (opt *ClaimCheckOption) checkIn(store BlobStore, stream string, fields map[string]string) (map[string]string, string, error) {
+	if internal.FieldsSize(fields) < opt.Threshold {
+		return fields, "", nil
+	}
+
+	id, err := internal.GenerateRandomID()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := BLOB_KEY_PREFIX + stream + ":" + id
+	data := internal.EncodeFields(fields)
+	err = store.Put(key, data, opt.TTL)
+	if err != nil {
+		return nil, "", err
+	}
+	// the hash is a field of the message, so the signature covers the content
+	return map[string]string{
+		FIELD_CLAIM_CHECK:      key,
+		FIELD_CLAIM_CHECK_HASH: computeBlobHash(data),
+	}, key, nil
+}
+
+func computeBlobHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveClaimCheck replaces the claim check reference of message with the
+// content, and keeps the key on ctx so the content can be released with
+// the message. The content must match FIELD_CLAIM_CHECK_HASH if the message
+// has one.
+func (c *Consumer) resolveClaimCheck(ctx *ConsumeContext, message *XMessage) error {
+	ref, ok := message.Values[FIELD_CLAIM_CHECK]
+	if !ok {
+		return nil
+	}
+
+	key, _ := ref.(string)
+	// the content is released with the message even if it is rejected
+	ctx.blobKey = key
+	data, err := c.blobStore.Get(key)
+	if err != nil {
+		return fmt.Errorf("cannot resolve claim check %q: %v", key, err)
+	}
+	if hash, ok := message.Values[FIELD_CLAIM_CHECK_HASH]; ok {
+		if hash != computeBlobHash(data) {
+			return fmt.Errorf("the content of claim check %q does not match its hash", key)
+		}
+	}
+	fields, err := internal.DecodeFields(data)
+	if err != nil {
+		return err
+	}
+
+	message.Values = mergeDecodedValues(fields, message.Values, FIELD_CLAIM_CHECK, FIELD_CLAIM_CHECK_HASH)
+	return nil
+}
+
+// claimCheckKeys returns the claim check keys of the messages ids on
+// stream. The key of the message being handled is kept on c; the others are
+// read from the stream, so it must be called before they are deleted.
+func (c *ConsumeContext) claimCheckKeys(stream string, ids ...string) []string {
+	var (
+		keys []string
+		cmds []*redis.XMessageSliceCmd
+		pipe = c.Handle().Pipeline()
+	)
+	for _, id := range ids {
+		if c.isHandling(stream, id) {
+			key := c.blobKey
+			if len(key) == 0 {
+				// rejected before it is decoded
+				key, _ = c.message.Values[FIELD_CLAIM_CHECK].(string)
+			}
+			if len(key) > 0 {
+				keys = append(keys, key)
+			}
+			continue
+		}
+		cmds = append(cmds, pipe.XRange(stream, id, id))
+	}
+	if len(cmds) == 0 {
+		return keys
+	}
+
+	_, err := pipe.Exec()
+	if err != nil {
+		if err != redis.Nil {
+			logger.Printf("%% Warning: cannot read the claim checks of %s: %v\n", stream, err)
+		}
+	}
+	for _, cmd := range cmds {
+		for _, message := range cmd.Val() {
+			if key, ok := message.Values[FIELD_CLAIM_CHECK].(string); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// releaseBlobs deletes the claim check contents of keys.
+func (c *ConsumeContext) releaseBlobs(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	deleteBlobs(c.consumer.blobStore, keys...)
+
+	for _, key := range keys {
+		if key == c.blobKey {
+			c.blobKey = ""
+		}
+	}
+}
+
+// deleteBlobs deletes the claim check contents of keys; a failure is
+// logged, the contents expire with ClaimCheckOption.TTL.
+func deleteBlobs(store BlobStore, keys ...string) {
+	if err := store.Delete(keys...); err != nil {
+		logger.Printf("%% Warning: cannot delete claim check blobs %v: %v\n", keys, err)
+	}
+}
@@ -0,0 +1,574 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
+)
+
+var cmdStreams = &command{
+	usage:       "[-match pattern]",
+	description: "list the streams",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("streams")
+		match := fs.String("match", "*", "key pattern of the streams")
+		if _, err := parseArgs(fs, args, 0, 0); err != nil {
+			return err
+		}
+
+		streams, err := app.admin.ListStreams(*match)
+		if err != nil {
+			return err
+		}
+
+		type streamView struct {
+			Name string
+			*redis.StreamInfo
+		}
+		var (
+			result = make([]*streamView, 0, len(streams))
+			t      = &table{header: []string{"STREAM", "LENGTH", "GROUPS", "LAST-GENERATED-ID"}}
+		)
+		for _, stream := range streams {
+			info, err := app.admin.StreamInfo(stream)
+			if err != nil {
+				return err
+			}
+			result = append(result, &streamView{Name: stream, StreamInfo: info})
+			t.append(stream, info.Length, info.Groups, info.LastGeneratedID)
+		}
+		return app.print(result, t)
+	},
+}
+
+var cmdDescribe = &command{
+	usage:       "stream <stream> | groups <stream> | consumers <stream> <group>",
+	description: "describe a stream, its consumer groups or the consumers of a group",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("describe")
+		args, err := parseArgs(fs, args, 2, 3)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case args[0] == "stream" && len(args) == 2:
+			info, err := app.admin.StreamInfo(args[1])
+			if err != nil {
+				return err
+			}
+			t := &table{}
+			t.append("STREAM", args[1])
+			t.append("LENGTH", info.Length)
+			t.append("GROUPS", info.Groups)
+			t.append("LAST-GENERATED-ID", info.LastGeneratedID)
+			t.append("MAX-DELETED-ENTRY-ID", info.MaxDeletedEntryID)
+			t.append("ENTRIES-ADDED", info.EntriesAdded)
+			t.append("RECORDED-FIRST-ENTRY-ID", info.RecordedFirstEntryID)
+			t.append("RADIX-TREE-KEYS", info.RadixTreeKeys)
+			t.append("RADIX-TREE-NODES", info.RadixTreeNodes)
+			if info.FirstEntry != nil {
+				t.append("FIRST-ENTRY", info.FirstEntry.ID+" "+formatValues(info.FirstEntry.Values))
+			}
+			if info.LastEntry != nil {
+				t.append("LAST-ENTRY", info.LastEntry.ID+" "+formatValues(info.LastEntry.Values))
+			}
+			return app.print(info, t)
+
+		case args[0] == "groups" && len(args) == 2:
+			groups, err := app.admin.GroupsInfo(args[1])
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"GROUP", "CONSUMERS", "PENDING", "LAST-DELIVERED-ID", "ENTRIES-READ", "LAG"}}
+			for _, g := range groups {
+				t.append(g.Name, g.Consumers, g.Pending, g.LastDeliveredID, g.EntriesRead, g.Lag)
+			}
+			return app.print(groups, t)
+
+		case args[0] == "consumers" && len(args) == 3:
+			consumers, err := app.admin.ConsumersInfo(args[1], args[2])
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"CONSUMER", "PENDING", "IDLE", "INACTIVE"}}
+			for _, c := range consumers {
+				t.append(c.Name, c.Pending, c.Idle, c.Inactive)
+			}
+			return app.print(consumers, t)
+		}
+		fs.Usage()
+		return errUsage
+	},
+}
+
+var cmdLag = &command{
+	usage:       "<group> <stream>...",
+	description: "report how far a consumer group is behind on streams",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("lag")
+		args, err := parseArgs(fs, args, 2, -1)
+		if err != nil {
+			return err
+		}
+
+		lags, err := app.admin.GroupLags(args[0], args[1:]...)
+		if err != nil {
+			return err
+		}
+		t := &table{header: []string{"STREAM", "GROUP", "LAST-DELIVERED-ID", "LAG", "PENDING", "OLDEST-PENDING", "OLDEST-UNDELIVERED"}}
+		for _, lag := range lags {
+			t.append(lag.Stream, lag.Group, lag.LastDeliveredID, lag.Lag, lag.Pending, lag.OldestPendingAge, lag.OldestUndeliveredAge)
+		}
+		return app.print(lags, t)
+	},
+}
+
+var cmdPending = &command{
+	usage:       "[flags] <stream> <group>",
+	description: "list the pending entries of a consumer group",
+	run: func(app *app, args []string) error {
+		var (
+			fs     = newFlagSet("pending")
+			filter = &redis.PendingFilter{}
+		)
+		fs.StringVar(&filter.Consumer, "consumer", "", "only the entries of the consumer")
+		fs.DurationVar(&filter.MinIdle, "min-idle", 0, "only the entries idle at least the duration")
+		fs.Int64Var(&filter.MinDeliveryCount, "min-deliveries", 0, "only the entries delivered at least the times")
+		fs.StringVar(&filter.Start, "start", "-", "the first ID")
+		fs.StringVar(&filter.End, "end", "+", "the last ID")
+		fs.Int64Var(&filter.Limit, "limit", 0, "the maximum number of entries, 0 means no limit")
+		messages := fs.Bool("messages", false, "show the messages of the entries")
+		args, err := parseArgs(fs, args, 2, 2)
+		if err != nil {
+			return err
+		}
+
+		var entries []*redis.PendingEntry
+		if *messages {
+			entries, err = app.admin.ListPendingMessages(args[0], args[1], filter)
+		} else {
+			entries, err = app.admin.ListPending(args[0], args[1], filter)
+		}
+		if err != nil {
+			return err
+		}
+
+		t := &table{header: []string{"ID", "CONSUMER", "IDLE", "DELIVERIES"}}
+		if *messages {
+			t.header = append(t.header, "MESSAGE")
+		}
+		for _, entry := range entries {
+			if *messages {
+				message := "(deleted)"
+				if entry.Message != nil {
+					message = formatValues(entry.Message.Values)
+				}
+				t.append(entry.ID, entry.Consumer, entry.Idle, entry.DeliveryCount, message)
+			} else {
+				t.append(entry.ID, entry.Consumer, entry.Idle, entry.DeliveryCount)
+			}
+		}
+		return app.print(entries, t)
+	},
+}
+
+var cmdCreateGroup = &command{
+	usage:       "[-start id] <stream> <group>",
+	description: "create a consumer group, and the stream if it does not exist",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("create-group")
+		start := fs.String("start", redis.StreamLastDeliveredID, "the ID after which the entries are delivered; 0 for all entries")
+		args, err := parseArgs(fs, args, 2, 2)
+		if err != nil {
+			return err
+		}
+
+		created, err := app.admin.EnsureConsumerGroup(args[0], args[1], *start)
+		if err != nil {
+			return err
+		}
+		result := map[string]interface{}{
+			"Stream":  args[0],
+			"Group":   args[1],
+			"Created": created,
+		}
+		t := &table{header: []string{"STREAM", "GROUP", "CREATED"}}
+		t.append(args[0], args[1], created)
+		return app.print(result, t)
+	},
+}
+
+var cmdDeleteGroup = &command{
+	usage:       "<stream> <group>",
+	description: "delete a consumer group with its consumers and pending entries",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("delete-group")
+		args, err := parseArgs(fs, args, 2, 2)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := app.admin.DeleteConsumerGroup(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		result := map[string]interface{}{
+			"Stream":  args[0],
+			"Group":   args[1],
+			"Deleted": deleted > 0,
+		}
+		t := &table{header: []string{"STREAM", "GROUP", "DELETED"}}
+		t.append(args[0], args[1], deleted > 0)
+		return app.print(result, t)
+	},
+}
+
+var cmdResetGroup = &command{
+	usage:       "(-to id|beginning|end | -at time | -ago duration | -back n) [-dry-run] <stream> <group>",
+	description: "move the last-delivered-id of a consumer group",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("reset-group")
+		to := fs.String("to", "", "an ID, beginning or end")
+		at := fs.String("at", "", "the time in RFC3339 from which the entries are delivered")
+		ago := fs.Duration("ago", 0, "deliver the entries added in the last duration")
+		back := fs.Int64("back", 0, "deliver the last n entries")
+		dryRun := fs.Bool("dry-run", false, "report the result without changing the group")
+		args, err := parseArgs(fs, args, 2, 2)
+		if err != nil {
+			return err
+		}
+
+		offset, err := parseGroupOffset(*to, *at, *ago, *back)
+		if err != nil {
+			fmt.Fprintln(fs.Output(), err)
+			fs.Usage()
+			return errUsage
+		}
+
+		result, err := app.admin.ResetConsumerGroupOffset(args[0], args[1], offset, *dryRun)
+		if err != nil {
+			return err
+		}
+		t := &table{header: []string{"STREAM", "GROUP", "PREVIOUS-ID", "ID", "REDELIVERED", "SKIPPED", "PENDING", "DRY-RUN"}}
+		t.append(result.Stream, result.Group, result.PreviousID, result.ID, result.Redelivered, result.Skipped, result.Pending, result.DryRun)
+		if len(result.Warning) > 0 {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", result.Warning)
+		}
+		return app.print(result, t)
+	},
+}
+
+// parseGroupOffset selects the offset of reset-group from its flags, of
+// which exactly one must be specified.
+func parseGroupOffset(to, at string, ago time.Duration, back int64) (redis.GroupOffset, error) {
+	var offsets []redis.GroupOffset
+	if len(to) > 0 {
+		switch to {
+		case "beginning":
+			offsets = append(offsets, redis.OffsetBeginning())
+		case "end":
+			offsets = append(offsets, redis.OffsetEnd())
+		default:
+			offsets = append(offsets, redis.OffsetID(to))
+		}
+	}
+	if len(at) > 0 {
+		since, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return redis.GroupOffset{}, err
+		}
+		offsets = append(offsets, redis.OffsetAtTime(since))
+	}
+	if ago > 0 {
+		offsets = append(offsets, redis.OffsetAgo(ago))
+	}
+	if back > 0 {
+		offsets = append(offsets, redis.OffsetBack(back))
+	}
+	if len(offsets) != 1 {
+		return redis.GroupOffset{}, fmt.Errorf("exactly one of -to, -at, -ago and -back must be specified")
+	}
+	return offsets[0], nil
+}
+
+var cmdTrim = &command{
+	usage:       "[-max-len n] [-max-age duration] [-safe] <stream>",
+	description: "trim the entries beyond the retention, which requires redis 6.2+",
+	run: func(app *app, args []string) error {
+		var (
+			fs        = newFlagSet("trim")
+			retention = &redis.RetentionSpec{}
+		)
+		fs.Int64Var(&retention.MaxLen, "max-len", 0, "the maximum number of entries to keep")
+		fs.DurationVar(&retention.MaxAge, "max-age", 0, "the maximum age of entries to keep")
+		safe := fs.Bool("safe", false, "never trim entries which are not consumed by every group")
+		args, err := parseArgs(fs, args, 1, 1)
+		if err != nil {
+			return err
+		}
+		if retention.MaxLen <= 0 && retention.MaxAge <= 0 {
+			fmt.Fprintln(fs.Output(), "-max-len or -max-age must be specified")
+			fs.Usage()
+			return errUsage
+		}
+
+		result, err := app.admin.ApplyRetention(args[0], retention, *safe)
+		if err != nil {
+			return err
+		}
+		t := &table{header: []string{"STREAM", "MIN-ID", "TRIMMED", "LIMITED"}}
+		t.append(result.Stream, result.MinID, result.Trimmed, result.Limited)
+		return app.print(result, t)
+	},
+}
+
+var cmdExport = &command{
+	usage:       "[-f file] <stream>",
+	description: "export a stream as JSON Lines",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("export")
+		file := fs.String("f", "-", "the output file, - for stdout")
+		args, err := parseArgs(fs, args, 1, 1)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = app.stdout
+		if *file != "-" {
+			f, err := os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		count, err := app.admin.ExportStream(args[0], w)
+		if err != nil {
+			return err
+		}
+		if *file != "-" {
+			result := map[string]interface{}{
+				"Stream":   args[0],
+				"File":     *file,
+				"Exported": count,
+			}
+			t := &table{header: []string{"STREAM", "FILE", "EXPORTED"}}
+			t.append(args[0], *file, count)
+			return app.print(result, t)
+		}
+		return nil
+	},
+}
+
+var cmdImport = &command{
+	usage:       "[-f file] [-stream name] [-preserve-id] [-restore-groups]",
+	description: "import a stream exported by export",
+	run: func(app *app, args []string) error {
+		var (
+			fs  = newFlagSet("import")
+			opt = &redis.ImportOption{}
+		)
+		file := fs.String("f", "-", "the input file, - for stdin")
+		fs.StringVar(&opt.Stream, "stream", "", "the stream to import to, the exported stream by default")
+		fs.BoolVar(&opt.PreserveID, "preserve-id", false, "keep the entry IDs")
+		fs.BoolVar(&opt.RestoreGroups, "restore-groups", false, "restore the consumer groups")
+		if _, err := parseArgs(fs, args, 0, 0); err != nil {
+			return err
+		}
+
+		var r io.Reader = app.stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		result, err := app.admin.ImportStream(r, opt)
+		if result != nil {
+			t := &table{header: []string{"STREAM", "IMPORTED", "SKIPPED", "GROUP", "LAST-DELIVERED-ID"}}
+			if len(result.Groups) == 0 {
+				t.append(result.Stream, result.Imported, result.Skipped, "", "")
+			}
+			for group, id := range result.Groups {
+				t.append(result.Stream, result.Imported, result.Skipped, group, id)
+			}
+			if perr := app.print(result, t); perr != nil && err == nil {
+				err = perr
+			}
+		}
+		return err
+	},
+}
+
+var cmdTail = &command{
+	usage:       "[-n count] [-f] <stream>",
+	description: "print the last entries of a stream",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("tail")
+		count := fs.Int64("n", 10, "the number of entries")
+		follow := fs.Bool("f", false, "keep printing the new entries")
+		args, err := parseArgs(fs, args, 1, 1)
+		if err != nil {
+			return err
+		}
+		stream := args[0]
+
+		// the last entries, printed from old to new
+		lastID := redis.StreamZeroID
+		if *count > 0 {
+			messages, err := app.admin.Handle().XRevRangeN(stream, "+", "-", *count).Result()
+			if err != nil {
+				if err != goredis.Nil {
+					return err
+				}
+			}
+			for i := len(messages) - 1; i >= 0; i-- {
+				if err := app.printMessage(stream, &messages[i]); err != nil {
+					return err
+				}
+			}
+			if len(messages) > 0 {
+				lastID = messages[0].ID
+			}
+		}
+		if !*follow {
+			return nil
+		}
+
+		if lastID == redis.StreamZeroID {
+			messages, err := app.admin.Handle().XRevRangeN(stream, "+", "-", 1).Result()
+			if err != nil {
+				if err != goredis.Nil {
+					return err
+				}
+			}
+			if len(messages) > 0 {
+				lastID = messages[0].ID
+			}
+		}
+		for {
+			streams, err := app.admin.Handle().XRead(&goredis.XReadArgs{
+				Streams: []string{stream, lastID},
+				Count:   redis.DEFAULT_RANGE_PAGE_SIZE,
+				Block:   redis.DEFAULT_MAX_POLLING_TIMEOUT,
+			}).Result()
+			if err != nil {
+				if err != goredis.Nil {
+					return err
+				}
+			}
+			for _, s := range streams {
+				for i := range s.Messages {
+					if err := app.printMessage(stream, &s.Messages[i]); err != nil {
+						return err
+					}
+					lastID = s.Messages[i].ID
+				}
+			}
+		}
+	},
+}
+
+var cmdReplayDeadLetters = &command{
+	usage:       "[-to stream] [-limit n] [-keep] [-dry-run] <dead-letter-stream>",
+	description: "move the entries of a dead letter stream back to their streams",
+	help: "Each entry holds the fields of the dead message plus " + redis.FIELD_SOURCE_STREAM + " and\n" +
+		redis.FIELD_SOURCE_ID + ", the stream and the ID it was read from, as written by\n" +
+		"redis.DeadLetterMessageHandler. The two fields are removed on replay; without\n" +
+		redis.FIELD_SOURCE_STREAM + " the entry is replayed to -to only.",
+	run: func(app *app, args []string) error {
+		fs := newFlagSet("replay-dead-letters")
+		to := fs.String("to", "", "the stream to replay to; by default the "+redis.FIELD_SOURCE_STREAM+" field of each entry")
+		limit := fs.Int64("limit", 0, "the maximum number of entries, 0 means no limit")
+		keep := fs.Bool("keep", false, "keep the entries in the dead letter stream")
+		dryRun := fs.Bool("dry-run", false, "report the entries without replaying them")
+		args, err := parseArgs(fs, args, 1, 1)
+		if err != nil {
+			return err
+		}
+		deadLetterStream := args[0]
+
+		type replayedEntry struct {
+			ID     string
+			Stream string
+			NewID  string
+			Error  string `json:",omitempty"`
+		}
+		var (
+			result []*replayedEntry
+			failed int
+			it     = app.admin.Range(deadLetterStream, "-", "+", nil)
+		)
+		for it.Next() {
+			if *limit > 0 && int64(len(result)) >= *limit {
+				break
+			}
+			var (
+				message = it.Message()
+				entry   = &replayedEntry{ID: message.ID, Stream: *to}
+				values  = make(map[string]interface{}, len(message.Values))
+			)
+			result = append(result, entry)
+
+			// the source fields describe the dead letter, not the message
+			for name, value := range message.Values {
+				if name == redis.FIELD_SOURCE_STREAM || name == redis.FIELD_SOURCE_ID {
+					continue
+				}
+				values[name] = value
+			}
+			if len(entry.Stream) == 0 {
+				entry.Stream, _ = message.Values[redis.FIELD_SOURCE_STREAM].(string)
+			}
+			if len(entry.Stream) == 0 {
+				entry.Error = "no target stream; specify -to"
+				failed++
+				continue
+			}
+			if *dryRun {
+				continue
+			}
+
+			entry.NewID, err = app.admin.Handle().XAdd(&goredis.XAddArgs{
+				Stream: entry.Stream,
+				Values: values,
+			}).Result()
+			if err != nil {
+				entry.Error = err.Error()
+				failed++
+				continue
+			}
+			if !*keep {
+				err = app.admin.Handle().XDel(deadLetterStream, message.ID).Err()
+				if err != nil {
+					entry.Error = "replayed but not deleted: " + err.Error()
+					failed++
+				}
+			}
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+
+		t := &table{header: []string{"ID", "STREAM", "NEW-ID", "ERROR"}}
+		for _, entry := range result {
+			t.append(entry.ID, entry.Stream, entry.NewID, entry.Error)
+		}
+		if err := app.print(result, t); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d entries failed", failed, len(result))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,243 @@
+// Command redis-stream inspects and manages redis streams and their
+// consumer groups.
+//
+//	redis-stream [connection flags] [-o table|json] <command> [flags] [args]
+//
+// The connection is given by -url, see redis.ParseRedisURL, or by -addrs
+// and the other connection flags; several -addrs make a cluster client and
+// -master a sentinel client. Without both, REDIS_URL is used if set,
+// otherwise localhost:6379.
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+const (
+	OUTPUT_TABLE string = "table"
+	OUTPUT_JSON  string = "json"
+
+	ENV_REDIS_URL string = "REDIS_URL"
+)
+
+var errUsage = errors.New("usage")
+
+type command struct {
+	usage       string
+	description string
+	help        string // 顯示於 description 之後的詳細說明
+	run         func(app *app, args []string) error
+}
+
+var commands map[string]*command
+
+func init() {
+	// assigned in init to break the initialization cycle through newFlagSet
+	commands = map[string]*command{
+		"streams":             cmdStreams,
+		"describe":            cmdDescribe,
+		"lag":                 cmdLag,
+		"pending":             cmdPending,
+		"create-group":        cmdCreateGroup,
+		"delete-group":        cmdDeleteGroup,
+		"reset-group":         cmdResetGroup,
+		"trim":                cmdTrim,
+		"export":              cmdExport,
+		"import":              cmdImport,
+		"tail":                cmdTail,
+		"replay-dead-letters": cmdReplayDeadLetters,
+	}
+}
+
+type app struct {
+	admin  *redis.AdminClient
+	output string
+	stdout io.Writer
+	stdin  io.Reader
+}
+
+type connectionFlags struct {
+	url           string
+	addrs         string
+	username      string
+	password      string
+	db            int
+	dbSet         bool
+	master        string
+	tls           bool
+	tlsSkipVerify bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	var (
+		conn   connectionFlags
+		output string
+		fs     = flag.NewFlagSet("redis-stream", flag.ContinueOnError)
+	)
+	fs.StringVar(&conn.url, "url", "", "redis URL, e.g. redis://:password@host:6379/0")
+	fs.StringVar(&conn.addrs, "addrs", "", "comma separated host:port; several addresses make a cluster client")
+	fs.StringVar(&conn.username, "username", "", "redis ACL username")
+	fs.StringVar(&conn.password, "password", "", "redis password")
+	fs.IntVar(&conn.db, "db", 0, "database, not for cluster")
+	fs.StringVar(&conn.master, "master", "", "sentinel master name; -addrs are the sentinels")
+	fs.BoolVar(&conn.tls, "tls", false, "connect with TLS")
+	fs.BoolVar(&conn.tlsSkipVerify, "tls-skip-verify", false, "skip the verification of the server certificate")
+	fs.StringVar(&output, "o", OUTPUT_TABLE, "output format, table or json")
+	fs.Usage = func() { usage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	// -db 0 must override the database of the URL too
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "db" {
+			conn.dbSet = true
+		}
+	})
+	if output != OUTPUT_TABLE && output != OUTPUT_JSON {
+		fmt.Fprintf(os.Stderr, "invalid output format %q\n", output)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		usage(fs)
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", fs.Arg(0))
+		usage(fs)
+		return 2
+	}
+
+	opt, err := conn.redisOption()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer admin.Close()
+
+	err = cmd.run(&app{
+		admin:  admin,
+		output: output,
+		stdout: os.Stdout,
+		stdin:  os.Stdin,
+	}, fs.Args()[1:])
+	if err != nil {
+		if err == errUsage || err == flag.ErrHelp {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	return 0
+}
+
+func (f *connectionFlags) redisOption() (*redis.UniversalOptions, error) {
+	if len(f.url) > 0 && len(f.addrs) > 0 {
+		return nil, fmt.Errorf("-url and -addrs cannot be used together")
+	}
+	if len(f.url) == 0 && len(f.addrs) == 0 {
+		f.url = os.Getenv(ENV_REDIS_URL)
+	}
+
+	var opt *redis.UniversalOptions
+	if len(f.url) > 0 {
+		var err error
+		opt, err = redis.ParseRedisURL(f.url)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		opt = &redis.UniversalOptions{
+			Addrs: []string{"localhost:" + redis.DEFAULT_REDIS_PORT},
+		}
+		if len(f.addrs) > 0 {
+			opt.Addrs = strings.Split(f.addrs, ",")
+		}
+	}
+
+	// the flags override the URL
+	if len(f.username) > 0 {
+		opt.Username = f.username
+	}
+	if len(f.password) > 0 {
+		opt.Password = f.password
+	}
+	if f.dbSet {
+		opt.DB = f.db
+	}
+	if len(f.master) > 0 {
+		opt.MasterName = f.master
+	}
+	if f.tls || f.tlsSkipVerify {
+		if opt.TLSConfig == nil {
+			opt.TLSConfig = &tls.Config{}
+		}
+		opt.TLSConfig.InsecureSkipVerify = f.tlsSkipVerify
+	}
+	return opt, nil
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintf(w, "Usage: redis-stream [connection flags] [-o table|json] <command> [flags] [args]\n\n")
+	fmt.Fprintf(w, "Commands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-20s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintf(w, "\nFlags:\n")
+	fs.PrintDefaults()
+}
+
+// newFlagSet creates the flag set of a command, which prints the usage of
+// the command on errors.
+func newFlagSet(name string) *flag.FlagSet {
+	cmd := commands[name]
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: redis-stream %s %s\n\n%s\n", name, cmd.usage, cmd.description)
+		if len(cmd.help) > 0 {
+			fmt.Fprintf(fs.Output(), "\n%s\n", cmd.help)
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags of a command and checks the number of the
+// remaining arguments, which must be between min and max; max < 0 means
+// no upper limit.
+func parseArgs(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, errUsage
+	}
+	if fs.NArg() < min || (max >= 0 && fs.NArg() > max) {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return fs.Args(), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestConnectionFlags_RedisOption(t *testing.T) {
+	defer os.Setenv(ENV_REDIS_URL, os.Getenv(ENV_REDIS_URL))
+
+	tests := []struct {
+		name          string
+		env           string
+		flags         connectionFlags
+		addrs         []string
+		username      string
+		password      string
+		db            int
+		masterName    string
+		tls           bool
+		tlsSkipVerify bool
+	}{
+		{"default", "", connectionFlags{}, []string{"localhost:6379"}, "", "", 0, "", false, false},
+		{"env", "redis://:secret@h1:7000/2", connectionFlags{}, []string{"h1:7000"}, "", "secret", 2, "", false, false},
+		{"url over env", "redis://h1", connectionFlags{url: "redis://h2"}, []string{"h2:6379"}, "", "", 0, "", false, false},
+		{"addrs over env", "redis://h1", connectionFlags{addrs: "h2:6380"}, []string{"h2:6380"}, "", "", 0, "", false, false},
+		{"cluster", "", connectionFlags{addrs: "h1:7000,h2:7001,h3:7002"}, []string{"h1:7000", "h2:7001", "h3:7002"}, "", "", 0, "", false, false},
+		{"sentinel", "", connectionFlags{addrs: "s1:26379,s2:26379", master: "mymaster"}, []string{"s1:26379", "s2:26379"}, "", "", 0, "mymaster", false, false},
+		{"flags over url", "", connectionFlags{url: "redis://user:secret@h1/1", username: "admin", password: "changed", db: 3, dbSet: true}, []string{"h1:6379"}, "admin", "changed", 3, "", false, false},
+		{"db 0 over url", "", connectionFlags{url: "redis://h1/1", db: 0, dbSet: true}, []string{"h1:6379"}, "", "", 0, "", false, false},
+		{"url keeps its settings", "", connectionFlags{url: "rediss://user:secret@h1/1"}, []string{"h1:6379"}, "user", "secret", 1, "", true, false},
+		{"tls", "", connectionFlags{addrs: "h1:6379", tls: true}, []string{"h1:6379"}, "", "", 0, "", true, false},
+		{"tls skip verify", "", connectionFlags{addrs: "h1:6379", tlsSkipVerify: true}, []string{"h1:6379"}, "", "", 0, "", true, true},
+		{"tls skip verify on rediss", "", connectionFlags{url: "rediss://h1", tlsSkipVerify: true}, []string{"h1:6379"}, "", "", 0, "", true, true},
+	}
+
+	for _, tt := range tests {
+		os.Setenv(ENV_REDIS_URL, tt.env)
+
+		opt, err := tt.flags.redisOption()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if strings.Join(opt.Addrs, ",") != strings.Join(tt.addrs, ",") {
+			t.Errorf("%s: expect addrs %v, but got %v", tt.name, tt.addrs, opt.Addrs)
+		}
+		if opt.Username != tt.username {
+			t.Errorf("%s: expect username %q, but got %q", tt.name, tt.username, opt.Username)
+		}
+		if opt.Password != tt.password {
+			t.Errorf("%s: expect password %q, but got %q", tt.name, tt.password, opt.Password)
+		}
+		if opt.DB != tt.db {
+			t.Errorf("%s: expect db %d, but got %d", tt.name, tt.db, opt.DB)
+		}
+		if opt.MasterName != tt.masterName {
+			t.Errorf("%s: expect master name %q, but got %q", tt.name, tt.masterName, opt.MasterName)
+		}
+		if (opt.TLSConfig != nil) != tt.tls {
+			t.Errorf("%s: expect tls %v, but got %v", tt.name, tt.tls, opt.TLSConfig != nil)
+		} else if opt.TLSConfig != nil && opt.TLSConfig.InsecureSkipVerify != tt.tlsSkipVerify {
+			t.Errorf("%s: expect tls skip verify %v, but got %v", tt.name, tt.tlsSkipVerify, opt.TLSConfig.InsecureSkipVerify)
+		}
+	}
+}
+
+func TestConnectionFlags_RedisOption_Invalid(t *testing.T) {
+	defer os.Setenv(ENV_REDIS_URL, os.Getenv(ENV_REDIS_URL))
+	os.Setenv(ENV_REDIS_URL, "")
+
+	for _, flags := range []connectionFlags{
+		{url: "redis://h1", addrs: "h2:6379"},
+		{url: "http://h1"},
+	} {
+		if _, err := flags.redisOption(); err == nil {
+			t.Errorf("expect error on %+v", flags)
+		}
+	}
+
+	os.Setenv(ENV_REDIS_URL, "http://h1")
+	flags := connectionFlags{}
+	if _, err := flags.redisOption(); err == nil {
+		t.Errorf("expect error on %s %q", ENV_REDIS_URL, "http://h1")
+	}
+}
+
+func TestParseGroupOffset(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		to     string
+		at     string
+		ago    time.Duration
+		back   int64
+		offset redis.GroupOffset
+	}{
+		{"beginning", "", 0, 0, redis.OffsetBeginning()},
+		{"end", "", 0, 0, redis.OffsetEnd()},
+		{"1526919030474-55", "", 0, 0, redis.OffsetID("1526919030474-55")},
+		{"", "2020-01-01T00:00:00Z", 0, 0, redis.OffsetAtTime(at)},
+		{"", "", time.Hour, 0, redis.OffsetAgo(time.Hour)},
+		{"", "", 0, 10, redis.OffsetBack(10)},
+	}
+
+	for _, tt := range tests {
+		offset, err := parseGroupOffset(tt.to, tt.at, tt.ago, tt.back)
+		if err != nil {
+			t.Errorf("%s: %v", tt.offset, err)
+			continue
+		}
+		if offset.String() != tt.offset.String() {
+			t.Errorf("expect offset %s, but got %s", tt.offset, offset)
+		}
+	}
+
+	invalids := []struct {
+		to   string
+		at   string
+		ago  time.Duration
+		back int64
+	}{
+		{"", "", 0, 0},
+		{"beginning", "", 0, 10},
+		{"", "2020-01-01T00:00:00Z", time.Hour, 0},
+		{"", "2020-01-01", 0, 0},
+		{"", "", -time.Hour, 0},
+		{"", "", 0, -1},
+	}
+
+	for _, tt := range invalids {
+		if _, err := parseGroupOffset(tt.to, tt.at, tt.ago, tt.back); err == nil {
+			t.Errorf("expect error on %+v", tt)
+		}
+	}
+}
+
+func TestFormatColumn(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  interface{}
+		column string
+	}{
+		{"gotestStream1", "gotestStream1"},
+		{"", "-"},
+		{time.Time{}, "-"},
+		{at, at.Local().Format(time.RFC3339)},
+		{1500*time.Microsecond + 300*time.Nanosecond, "2ms"},
+		{time.Duration(0), "0s"},
+		{time.Duration(-1), "-"},
+		{int64(42), "42"},
+		{int64(0), "0"},
+		{int64(-1), "-"},
+		{true, "yes"},
+		{false, "no"},
+		{3, "3"},
+		{-1, "-1"},
+		{[]string{"a", "b"}, fmt.Sprint([]string{"a", "b"})},
+	}
+
+	for _, tt := range tests {
+		if column := formatColumn(tt.value); column != tt.column {
+			t.Errorf("expect %q for %#v, but got %q", tt.column, tt.value, column)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	"github.com/bcowtech/lib-redis-stream/internal"
+)
+
+// table is the table output of a command; the json output is the value
+// passed to app.print instead.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) append(columns ...interface{}) {
+	row := make([]string, 0, len(columns))
+	for _, column := range columns {
+		row = append(row, formatColumn(column))
+	}
+	t.rows = append(t.rows, row)
+}
+
+func (a *app) print(v interface{}, t *table) error {
+	if a.output == OUTPUT_JSON {
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	if len(t.header) > 0 {
+		fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	}
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printMessage prints a message on one line, so messages can be streamed
+// by tail.
+func (a *app) printMessage(stream string, message *redis.XMessage) error {
+	if a.output == OUTPUT_JSON {
+		return json.NewEncoder(a.stdout).Encode(newMessageView(stream, message))
+	}
+	_, err := fmt.Fprintf(a.stdout, "%s\t%s\t%s\n",
+		message.ID, formatColumn(messageTime(message.ID)), formatValues(message.Values))
+	return err
+}
+
+type messageView struct {
+	Stream string            `json:"stream,omitempty"`
+	ID     string            `json:"id"`
+	Time   time.Time         `json:"time"`
+	Values map[string]string `json:"values"`
+}
+
+func newMessageView(stream string, message *redis.XMessage) *messageView {
+	values, err := internal.NormalizeValues(message.Values)
+	if err != nil {
+		values = map[string]string{}
+		for name, value := range message.Values {
+			values[name] = fmt.Sprint(value)
+		}
+	}
+	return &messageView{
+		Stream: stream,
+		ID:     message.ID,
+		Time:   messageTime(message.ID),
+		Values: values,
+	}
+}
+
+func messageTime(id string) time.Time {
+	streamID, err := internal.ParseStreamID(id)
+	if err != nil {
+		return time.Time{}
+	}
+	return streamID.Time()
+}
+
+func formatValues(values map[string]interface{}) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, fmt.Sprint(values[name])))
+	}
+	return strings.Join(pairs, " ")
+}
+
+func formatColumn(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if len(v) == 0 {
+			return "-"
+		}
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return "-"
+		}
+		return v.Local().Format(time.RFC3339)
+	case time.Duration:
+		if v < 0 {
+			return "-"
+		}
+		return v.Round(time.Millisecond).String()
+	case int64:
+		if v < 0 {
+			return "-"
+		}
+		return fmt.Sprint(v)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	GzipCompressor   Compressor = gzipCompressor{}
+	SnappyCompressor Compressor = snappyCompressor{}
+	ZstdCompressor   Compressor = &zstdCompressor{}
+
+	compressorRegistry = map[string]Compressor{
+		GzipCompressor.Name():   GzipCompressor,
+		SnappyCompressor.Name(): SnappyCompressor,
+		ZstdCompressor.Name():   ZstdCompressor,
+	}
+	compressorRegistryMutex sync.RWMutex
+)
+
+// Compressor compresses the message content written by the Producer. The
+// Name is stored in the message, so the Consumer can find the Compressor
+// to decompress it.
+type Compressor interface {
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// RegisterCompressor makes a Compressor available to the Consumer, e.g. a
+// lz4 implementation.
+func RegisterCompressor(compressor Compressor) {
+	compressorRegistryMutex.Lock()
+	defer compressorRegistryMutex.Unlock()
+
+	compressorRegistry[compressor.Name()] = compressor
+}
+
+func findCompressor(name string) Compressor {
+	compressorRegistryMutex.RLock()
+	defer compressorRegistryMutex.RUnlock()
+
+	return compressorRegistry[name]
+}
+
+type CompressionOption struct {
+	Compressor Compressor // 預設為 GzipCompressor
+	Threshold  int        // 訊息內容的大小 (bytes) 大於等於 n 時才壓縮
+}
+
+func (opt *CompressionOption) compress(fields map[string]string) (map[string]string, error) {
+	if internal.FieldsSize(fields) < opt.Threshold {
+		return fields, nil
+	}
+
+	var compressor = opt.Compressor
+	if compressor == nil {
+		compressor = GzipCompressor
+	}
+
+	data, err := compressor.Compress(internal.EncodeFields(fields))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		FIELD_CONTENT:          string(data),
+		FIELD_CONTENT_ENCODING: compressor.Name(),
+	}, nil
+}
+
+func decompressMessage(message *XMessage) error {
+	encoding, ok := message.Values[FIELD_CONTENT_ENCODING]
+	if !ok {
+		return nil
+	}
+
+	name, _ := encoding.(string)
+	compressor := findCompressor(name)
+	if compressor == nil {
+		return fmt.Errorf("unknown content encoding %q", name)
+	}
+
+	content, _ := message.Values[FIELD_CONTENT].(string)
+	data, err := compressor.Decompress([]byte(content))
+	if err != nil {
+		return err
+	}
+	fields, err := internal.DecodeFields(data)
+	if err != nil {
+		return err
+	}
+
+	message.Values = mergeDecodedValues(fields, message.Values, FIELD_CONTENT, FIELD_CONTENT_ENCODING)
+	return nil
+}
+
+// mergeDecodedValues returns the decoded fields together with the fields
+// of the original values which are not consumed by the decoder, e.g. the
+// idempotency key or the signature.
+func mergeDecodedValues(fields map[string]string, values map[string]interface{}, consumed ...string) map[string]interface{} {
+	result := internal.ToValues(fields)
+	for k, v := range values {
+		if _, ok := result[k]; ok {
+			continue
+		}
+		result[k] = v
+	}
+	for _, k := range consumed {
+		if _, ok := fields[k]; !ok {
+			delete(result, k)
+		}
+	}
+	return result
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string { return "gzip" }
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Name() string { return "snappy" }
+
+func (snappyCompressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (snappyCompressor) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+// zstdCompressor uses github.com/klauspost/compress/zstd, which is pure Go;
+// v1.12.3 is the last release supporting go 1.13, newer ones need go 1.15.
+// The encoder and decoder are created on first use and shared, EncodeAll
+// and DecodeAll are safe for concurrent use.
+type zstdCompressor struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+func (*zstdCompressor) Name() string { return "zstd" }
+
+func (c *zstdCompressor) Compress(data []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c.decoder.DecodeAll(data, nil)
+}
+
+func (c *zstdCompressor) init() error {
+	c.once.Do(func() {
+		c.encoder, c.err = zstd.NewWriter(nil)
+		if c.err != nil {
+			return
+		}
+		c.decoder, c.err = zstd.NewReader(nil)
+	})
+	return c.err
+}
@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration which is written as "1s" or "500ms" in
+// configuration files and environment variables.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Config is the declarative configuration of the Consumer and Producer.
+// Fields can be loaded from YAML, JSON or environment variables; the env
+// tags are the variable names without prefix.
+type Config struct {
+	Redis    RedisConfig    `yaml:"redis"    json:"redis"`
+	Consumer ConsumerConfig `yaml:"consumer" json:"consumer"`
+	Producer ProducerConfig `yaml:"producer" json:"producer"`
+}
+
+type RedisConfig struct {
+	URL string `yaml:"url" json:"url" env:"REDIS_URL"` // redis://, rediss:// 或 redis-sentinel://, 參考 ParseRedisURL
+}
+
+type ConsumerConfig struct {
+	Group               string   `yaml:"group"                 json:"group"                 env:"CONSUMER_GROUP"`
+	Name                string   `yaml:"name"                  json:"name"                  env:"CONSUMER_NAME"`
+	MaxInFlight         int64    `yaml:"max_in_flight"         json:"max_in_flight"         env:"CONSUMER_MAX_IN_FLIGHT"`
+	MaxPollingTimeout   Duration `yaml:"max_polling_timeout"   json:"max_polling_timeout"   env:"CONSUMER_MAX_POLLING_TIMEOUT"`
+	ClaimMinIdleTime    Duration `yaml:"claim_min_idle_time"   json:"claim_min_idle_time"   env:"CONSUMER_CLAIM_MIN_IDLE_TIME"`
+	IdlingTimeout       Duration `yaml:"idling_timeout"        json:"idling_timeout"        env:"CONSUMER_IDLING_TIMEOUT"`
+	ClaimSensitivity    int      `yaml:"claim_sensitivity"     json:"claim_sensitivity"     env:"CONSUMER_CLAIM_SENSITIVITY"`
+	ClaimOccurrenceRate int32    `yaml:"claim_occurrence_rate" json:"claim_occurrence_rate" env:"CONSUMER_CLAIM_OCCURRENCE_RATE"`
+}
+
+type ProducerConfig struct {
+	IdempotencyWindow Duration `yaml:"idempotency_window" json:"idempotency_window" env:"PRODUCER_IDEMPOTENCY_WINDOW"`
+}
+
+// LoadConfig loads the configuration file by its extension, .yaml, .yml
+// or .json, and then overrides it with the environment variables prefixed
+// with envPrefix, e.g. "APP" reads APP_REDIS_URL. Empty envPrefix skips
+// the environment variables.
+func LoadConfig(path string, envPrefix string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config = &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = config.LoadYAML(data)
+	case ".json":
+		err = config.LoadJSON(data)
+	default:
+		err = fmt.Errorf("unsupported configuration file %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envPrefix) > 0 {
+		err = config.LoadEnv(envPrefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return config, config.Validate()
+}
+
+func (c *Config) LoadYAML(data []byte) error {
+	return yaml.Unmarshal(data, c)
+}
+
+func (c *Config) LoadJSON(data []byte) error {
+	return json.Unmarshal(data, c)
+}
+
+// LoadEnv overrides the fields with the environment variables named
+// prefix + "_" + the env tag. Unset variables are ignored.
+func (c *Config) LoadEnv(prefix string) error {
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	return loadEnv(reflect.ValueOf(c).Elem(), prefix)
+}
+
+// Validate reports the invalid fields. The unspecified consumer settings
+// are left zero and get their defaults from Consumer.Validate.
+func (c *Config) Validate() error {
+	if len(c.Redis.URL) == 0 {
+		return fmt.Errorf("the redis.url is not specified")
+	}
+	if _, err := ParseRedisURL(c.Redis.URL); err != nil {
+		return err
+	}
+
+	consumer := &c.Consumer
+	switch {
+	case consumer.MaxInFlight < 0:
+		return fmt.Errorf("the consumer.max_in_flight must not be negative")
+	case consumer.MaxPollingTimeout < 0:
+		return fmt.Errorf("the consumer.max_polling_timeout must not be negative")
+	case consumer.ClaimMinIdleTime < 0:
+		return fmt.Errorf("the consumer.claim_min_idle_time must not be negative")
+	case consumer.IdlingTimeout < 0:
+		return fmt.Errorf("the consumer.idling_timeout must not be negative")
+	case consumer.ClaimSensitivity < 0:
+		return fmt.Errorf("the consumer.claim_sensitivity must not be negative")
+	case consumer.ClaimOccurrenceRate < 0:
+		return fmt.Errorf("the consumer.claim_occurrence_rate must not be negative")
+	case c.Producer.IdempotencyWindow < 0:
+		return fmt.Errorf("the producer.idempotency_window must not be negative")
+	}
+	return nil
+}
+
+func (c *Config) RedisOption() (*UniversalOptions, error) {
+	return ParseRedisURL(c.Redis.URL)
+}
+
+// NewConsumer creates a Consumer with the configuration and the defaults of
+// Consumer.Validate; MessageHandler and the other handlers must be
+// specified before Subscribe.
+func (c *Config) NewConsumer() (*Consumer, error) {
+	opt, err := c.RedisOption()
+	if err != nil {
+		return nil, err
+	}
+	consumer := &Consumer{
+		Group:               c.Consumer.Group,
+		Name:                c.Consumer.Name,
+		RedisOption:         opt,
+		MaxInFlight:         c.Consumer.MaxInFlight,
+		MaxPollingTimeout:   time.Duration(c.Consumer.MaxPollingTimeout),
+		ClaimMinIdleTime:    time.Duration(c.Consumer.ClaimMinIdleTime),
+		IdlingTimeout:       time.Duration(c.Consumer.IdlingTimeout),
+		ClaimSensitivity:    c.Consumer.ClaimSensitivity,
+		ClaimOccurrenceRate: c.Consumer.ClaimOccurrenceRate,
+	}
+	consumer.applyDefaults()
+	return consumer, nil
+}
+
+func (c *Config) NewProducer() (*Producer, error) {
+	opt, err := c.RedisOption()
+	if err != nil {
+		return nil, err
+	}
+	producer, err := NewProducer(opt)
+	if err != nil {
+		return nil, err
+	}
+	producer.IdempotencyWindow = time.Duration(c.Producer.IdempotencyWindow)
+	return producer, nil
+}
+
+func loadEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		var (
+			field = t.Field(i)
+			value = v.Field(i)
+		)
+		if field.Type.Kind() == reflect.Struct {
+			if err := loadEnv(value, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if len(name) == 0 {
+			continue
+		}
+		name = prefix + name
+		text, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch ptr := value.Addr().Interface().(type) {
+		case *string:
+			*ptr = text
+		case *Duration:
+			err = ptr.UnmarshalText([]byte(text))
+		case *int, *int32, *int64:
+			var n int64
+			n, err = strconv.ParseInt(text, 10, field.Type.Bits())
+			if err == nil {
+				value.SetInt(n)
+			}
+		default:
+			err = fmt.Errorf("unsupported type %s", field.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid environment variable %s=%q: %v", name, text, err)
+		}
+	}
+	return nil
+}
@@ -10,6 +10,12 @@ type ConsumeContext struct {
 	unhandledMessageHandler MessageHandleProc
 
 	consumer *Consumer
+
+	// the message being handled
+	stream       string
+	message      *XMessage
+	processedKey string
+	blobKey      string // claim check 訊息內容的 key
 }
 
 // get redis client
@@ -26,11 +32,58 @@ func (c *ConsumeContext) ConsumerName() string {
 }
 
 func (c *ConsumeContext) Ack(key string, id ...string) (int64, error) {
-	return c.consumer.handle.Ack(key, id...)
+	var (
+		reply int64
+		err   error
+	)
+	if c.consumer.Deduplication != nil && len(c.processedKey) > 0 && c.isHandling(key, id...) {
+		reply, err = c.consumer.ackProcessed(key, c.processedKey, id...)
+	} else {
+		reply, err = c.consumer.handle.Ack(key, id...)
+	}
+	if err != nil {
+		return reply, err
+	}
+
+	if c.consumer.ReleaseBlobOnAck {
+		c.releaseBlobs(c.claimCheckKeys(key, id...))
+	}
+	return reply, nil
 }
 
 func (c *ConsumeContext) Del(key string, id ...string) (int64, error) {
-	return c.consumer.handle.Del(key, id...)
+	blobKeys := c.claimCheckKeys(key, id...)
+
+	reply, err := c.consumer.handle.Del(key, id...)
+	if err != nil {
+		return reply, err
+	}
+
+	c.releaseBlobs(blobKeys)
+	return reply, nil
+}
+
+// isHandling reports whether the message being handled is one of ids on
+// stream.
+func (c *ConsumeContext) isHandling(stream string, ids ...string) bool {
+	if c.message == nil || stream != c.stream {
+		return false
+	}
+	for _, id := range ids {
+		if id == c.message.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ConsumeContext) withMessage(stream string, message *XMessage) *ConsumeContext {
+	return &ConsumeContext{
+		consumer:                c.consumer,
+		unhandledMessageHandler: c.unhandledMessageHandler,
+		stream:                  stream,
+		message:                 message,
+	}
 }
 
 func (c *ConsumeContext) ForwardUnhandledMessage(stream string, message *XMessage) {
@@ -38,6 +91,10 @@ func (c *ConsumeContext) ForwardUnhandledMessage(stream string, message *XMessag
 		ctx := &ConsumeContext{
 			consumer:                c.consumer,
 			unhandledMessageHandler: StopRecursiveForwardUnhandledMessageHandler,
+			stream:                  stream,
+			message:                 message,
+			processedKey:            c.processedKey,
+			blobKey:                 c.blobKey,
 		}
 		c.unhandledMessageHandler(ctx, stream, message)
 	}
@@ -21,12 +21,21 @@ type Consumer struct {
 	MessageHandler          MessageHandleProc
 	UnhandledMessageHandler MessageHandleProc
 	ErrorHandler            RedisErrorHandleProc
+	BlobStore               BlobStore               // claim-check 訊息內容的儲存位置, 預設使用 Consumer 的 redis
+	ReleaseBlobOnAck        bool                    // Ack 後刪除 claim-check 訊息內容; 若有多個 group 消費同一個 stream 不可啟用
+	KeyProvider             KeyProvider             // 解密訊息使用的金鑰
+	Middlewares             []MessageMiddlewareProc // 依序套用在 MessageHandler 之前, 處理的是尚未解碼的訊息
+	Deduplication           *DeduplicationOption    // 略過已處理過的訊息
 
 	handle   *internal.Consumer
 	stopChan chan bool
 	wg       sync.WaitGroup
 
-	claimTrigger *internal.CyclicCounter
+	claimTrigger   *internal.CyclicCounter
+	messageHandler MessageHandleProc
+	fatalHandler   func(err error) // 無法處理的錯誤; 未指定時結束程式
+
+	blobStore BlobStore
 
 	mutex       sync.Mutex
 	initialized bool
@@ -55,6 +64,9 @@ func (c *Consumer) Subscribe(streams ...StreamOffset) error {
 	if len(streams) == 0 {
 		return nil
 	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
 	c.init()
 	c.running = true
 
@@ -74,6 +86,12 @@ func (c *Consumer) Subscribe(streams ...StreamOffset) error {
 		c.handle = consumer
 	}
 
+	if c.BlobStore != nil {
+		c.blobStore = c.BlobStore
+	} else {
+		c.blobStore = NewRedisBlobStore(c.getRedisClient())
+	}
+
 	// reset
 	c.claimTrigger.Reset()
 
@@ -84,8 +102,8 @@ func (c *Consumer) Subscribe(streams ...StreamOffset) error {
 		}
 	)
 
+	c.wg.Add(1)
 	go func() {
-		c.wg.Add(1)
 		defer c.wg.Done()
 
 		defer c.handle.Close()
@@ -99,7 +117,7 @@ func (c *Consumer) Subscribe(streams ...StreamOffset) error {
 				err := c.processMessage(ctx)
 				if err != nil {
 					if !c.processRedisError(err) {
-						logger.Fatalf("%% Error: %v\n", err)
+						c.fail(err)
 						return
 					}
 				}
@@ -142,6 +160,17 @@ func (c *Consumer) init() {
 	if c.claimTrigger == nil {
 		c.claimTrigger = internal.NewCyclicCounter(c.ClaimOccurrenceRate)
 	}
+
+	if c.messageHandler == nil {
+		var handler MessageHandleProc = c.handleMessage
+		if c.Deduplication != nil {
+			handler = c.deduplicate(handler)
+		}
+		for i := len(c.Middlewares) - 1; i >= 0; i-- {
+			handler = c.Middlewares[i](handler)
+		}
+		c.messageHandler = handler
+	}
 	c.initialized = true
 }
 
@@ -152,6 +181,14 @@ func (c *Consumer) processRedisError(err error) (disposed bool) {
 	return false
 }
 
+func (c *Consumer) fail(err error) {
+	if c.fatalHandler != nil {
+		c.fatalHandler(err)
+		return
+	}
+	logger.Fatalf("%% Error: %v\n", err)
+}
+
 func (c *Consumer) getRedisClient() redis.UniversalClient {
 	return c.handle.Handle()
 }
@@ -173,7 +210,7 @@ func (c *Consumer) processMessage(ctx *ConsumeContext) error {
 		if len(streams) > 0 {
 			for _, stream := range streams {
 				for _, message := range stream.Messages {
-					c.MessageHandler(ctx, stream.Stream, &message)
+					c.dispatchMessage(ctx, stream.Stream, &message)
 					readMessages++
 				}
 			}
@@ -196,7 +233,7 @@ func (c *Consumer) processMessage(ctx *ConsumeContext) error {
 		if len(streams) > 0 {
 			for _, stream := range streams {
 				for _, message := range stream.Messages {
-					c.MessageHandler(ctx, stream.Stream, &message)
+					c.dispatchMessage(ctx, stream.Stream, &message)
 				}
 			}
 			return nil
@@ -209,6 +246,33 @@ func (c *Consumer) processMessage(ctx *ConsumeContext) error {
 	return nil
 }
 
+func (c *Consumer) dispatchMessage(ctx *ConsumeContext, stream string, message *XMessage) {
+	c.messageHandler(ctx.withMessage(stream, message), stream, message)
+}
+
+func (c *Consumer) handleMessage(ctx *ConsumeContext, stream string, message *XMessage) {
+	err := c.decodeMessage(ctx, message)
+	if err != nil {
+		logger.Printf("%% Warning: cannot decode message %s on %s: %v\n", message.ID, stream, err)
+		ctx.ForwardUnhandledMessage(stream, message)
+		return
+	}
+
+	c.MessageHandler(ctx, stream, message)
+}
+
+func (c *Consumer) decodeMessage(ctx *ConsumeContext, message *XMessage) error {
+	err := c.resolveClaimCheck(ctx, message)
+	if err != nil {
+		return err
+	}
+	err = decryptMessage(c.KeyProvider, message)
+	if err != nil {
+		return err
+	}
+	return decompressMessage(message)
+}
+
 func (c *Consumer) computePendingFetchingSize(maxInFlight int64) int64 {
 	var (
 		fetchingSize = maxInFlight * PENDING_FETCHING_SIZE_COEFFICIENT
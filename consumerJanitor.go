@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConsumerJanitor prunes the idle consumers of a group periodically. See
+// AdminClient.PruneConsumers.
+type ConsumerJanitor struct {
+	RedisOption   *UniversalOptions
+	Group         string
+	Streams       []string
+	IdleThreshold time.Duration // 閒置超過多久的 consumer 被刪除
+	Heir          string        // 接收被刪除 consumer 的 pending 訊息
+	Interval      time.Duration // 執行間隔, 預設為 DEFAULT_JANITOR_INTERVAL
+
+	admin  *AdminClient
+	worker periodicWorker
+}
+
+// Janitor creates a ConsumerJanitor for the group of c; the pending entries
+// of the pruned consumers are claimed to c.
+func (c *Consumer) Janitor(idleThreshold time.Duration, streams ...string) *ConsumerJanitor {
+	return &ConsumerJanitor{
+		RedisOption:   c.RedisOption,
+		Group:         c.Group,
+		Streams:       streams,
+		IdleThreshold: idleThreshold,
+		Heir:          c.Name,
+	}
+}
+
+var _ Runner = new(ConsumerJanitor)
+
+func (j *ConsumerJanitor) Start() error {
+	if j.IdleThreshold <= 0 {
+		return fmt.Errorf("the ConsumerJanitor.IdleThreshold must be positive")
+	}
+
+	admin, err := NewAdminClient(j.RedisOption)
+	if err != nil {
+		return err
+	}
+	j.admin = admin
+
+	interval := j.Interval
+	if interval <= 0 {
+		interval = DEFAULT_JANITOR_INTERVAL
+	}
+	j.worker.start(interval, j.prune)
+	return nil
+}
+
+func (j *ConsumerJanitor) Stop() {
+	if j.worker.stop() {
+		j.admin.Close()
+	}
+}
+
+// Err reports the pruning error which stops the ConsumerJanitor; see
+// periodicWorker.
+func (j *ConsumerJanitor) Err() <-chan error {
+	return j.worker.err()
+}
+
+// prune prunes the consumers on every stream; it fails if any stream
+// fails.
+func (j *ConsumerJanitor) prune() error {
+	var lastErr error
+	for _, stream := range j.Streams {
+		pruned, err := j.admin.PruneConsumers(stream, j.Group, j.IdleThreshold, j.Heir)
+		if err != nil {
+			lastErr = fmt.Errorf("cannot prune consumers of %s on %s: %v", j.Group, stream, err)
+			continue
+		}
+		for _, consumer := range pruned {
+			if consumer.Deleted {
+				logger.Printf("pruned consumer %s of %s on %s; %d pending entries claimed\n",
+					consumer.Name, j.Group, stream, consumer.Claimed)
+			}
+		}
+	}
+	return lastErr
+}
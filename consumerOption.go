@@ -0,0 +1,171 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+type ConsumerOption func(c *Consumer)
+
+// NewConsumer creates a Consumer with opts and validates it. See
+// Consumer.Validate for the default values.
+func NewConsumer(opts ...ConsumerOption) (*Consumer, error) {
+	c := &Consumer{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate applies the default values to the unspecified settings and
+// reports the invalid ones. It is called by Subscribe.
+//
+// Defaults of the zero values:
+//
+//	MaxInFlight        DEFAULT_MAX_IN_FLIGHT
+//	MaxPollingTimeout  DEFAULT_MAX_POLLING_TIMEOUT
+//	ClaimMinIdleTime   DEFAULT_CLAIM_MIN_IDLE_TIME
+//	IdlingTimeout      DEFAULT_IDLING_TIMEOUT
+//
+// Zero ClaimSensitivity or ClaimOccurrenceRate disables the corresponding
+// claim trigger; if both are zero, they are set to DEFAULT_CLAIM_SENSITIVITY
+// and DEFAULT_CLAIM_OCCURRENCE_RATE since pending messages would never be
+// claimed otherwise.
+func (c *Consumer) Validate() error {
+	c.applyDefaults()
+
+	switch {
+	case len(c.Group) == 0:
+		return fmt.Errorf("the Consumer.Group is not specified")
+	case len(c.Name) == 0:
+		return fmt.Errorf("the Consumer.Name is not specified")
+	case c.RedisOption == nil:
+		return fmt.Errorf("the Consumer.RedisOption is not specified")
+	case c.MessageHandler == nil:
+		return fmt.Errorf("the Consumer.MessageHandler is not specified")
+	case c.MaxInFlight < 0:
+		return fmt.Errorf("the Consumer.MaxInFlight must be positive, but got %d", c.MaxInFlight)
+	case c.MaxPollingTimeout < 0:
+		return fmt.Errorf("the Consumer.MaxPollingTimeout must be positive, but got %v", c.MaxPollingTimeout)
+	case c.ClaimMinIdleTime < 0:
+		return fmt.Errorf("the Consumer.ClaimMinIdleTime must be positive, but got %v", c.ClaimMinIdleTime)
+	case c.IdlingTimeout < 0:
+		return fmt.Errorf("the Consumer.IdlingTimeout must be positive, but got %v", c.IdlingTimeout)
+	case c.ClaimSensitivity < 0:
+		return fmt.Errorf("the Consumer.ClaimSensitivity must not be negative, but got %d", c.ClaimSensitivity)
+	case c.ClaimOccurrenceRate < 0:
+		return fmt.Errorf("the Consumer.ClaimOccurrenceRate must not be negative, but got %d", c.ClaimOccurrenceRate)
+	case c.Deduplication != nil && c.Deduplication.Window < 0:
+		return fmt.Errorf("the Consumer.Deduplication.Window must not be negative, but got %v", c.Deduplication.Window)
+	}
+	return nil
+}
+
+func (c *Consumer) applyDefaults() {
+	if c.MaxInFlight == 0 {
+		c.MaxInFlight = DEFAULT_MAX_IN_FLIGHT
+	}
+	if c.MaxPollingTimeout == 0 {
+		c.MaxPollingTimeout = DEFAULT_MAX_POLLING_TIMEOUT
+	}
+	if c.ClaimMinIdleTime == 0 {
+		c.ClaimMinIdleTime = DEFAULT_CLAIM_MIN_IDLE_TIME
+	}
+	if c.IdlingTimeout == 0 {
+		c.IdlingTimeout = DEFAULT_IDLING_TIMEOUT
+	}
+	if c.ClaimSensitivity == 0 && c.ClaimOccurrenceRate == 0 {
+		// pending messages would never be claimed
+		c.ClaimSensitivity = DEFAULT_CLAIM_SENSITIVITY
+		c.ClaimOccurrenceRate = DEFAULT_CLAIM_OCCURRENCE_RATE
+	}
+}
+
+func WithGroup(group string) ConsumerOption {
+	return func(c *Consumer) {
+		c.Group = group
+	}
+}
+
+func WithName(name string) ConsumerOption {
+	return func(c *Consumer) {
+		c.Name = name
+	}
+}
+
+func WithRedisOption(opt *UniversalOptions) ConsumerOption {
+	return func(c *Consumer) {
+		c.RedisOption = opt
+	}
+}
+
+func WithMaxInFlight(n int64) ConsumerOption {
+	return func(c *Consumer) {
+		c.MaxInFlight = n
+	}
+}
+
+func WithMaxPollingTimeout(d time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		c.MaxPollingTimeout = d
+	}
+}
+
+func WithClaimMinIdleTime(d time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		c.ClaimMinIdleTime = d
+	}
+}
+
+func WithIdlingTimeout(d time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		c.IdlingTimeout = d
+	}
+}
+
+func WithClaimSensitivity(n int) ConsumerOption {
+	return func(c *Consumer) {
+		c.ClaimSensitivity = n
+	}
+}
+
+func WithClaimOccurrenceRate(n int32) ConsumerOption {
+	return func(c *Consumer) {
+		c.ClaimOccurrenceRate = n
+	}
+}
+
+func WithMessageHandler(handler MessageHandleProc) ConsumerOption {
+	return func(c *Consumer) {
+		c.MessageHandler = handler
+	}
+}
+
+func WithUnhandledMessageHandler(handler MessageHandleProc) ConsumerOption {
+	return func(c *Consumer) {
+		c.UnhandledMessageHandler = handler
+	}
+}
+
+func WithErrorHandler(handler RedisErrorHandleProc) ConsumerOption {
+	return func(c *Consumer) {
+		c.ErrorHandler = handler
+	}
+}
+
+func WithMiddlewares(middlewares ...MessageMiddlewareProc) ConsumerOption {
+	return func(c *Consumer) {
+		c.Middlewares = append(c.Middlewares, middlewares...)
+	}
+}
+
+func WithDeduplication(opt *DeduplicationOption) ConsumerOption {
+	return func(c *Consumer) {
+		c.Deduplication = opt
+	}
+}
@@ -0,0 +1,37 @@
+package redis
+
+type ConsumerRunner struct {
+	handle  *Consumer
+	streams []StreamOffset
+	errChan chan error
+}
+
+func (c *Consumer) Runner(streams ...StreamOffset) *ConsumerRunner {
+	errChan, fail := runnerErrorChan()
+	c.fatalHandler = fail
+
+	return &ConsumerRunner{
+		handle:  c,
+		streams: streams,
+		errChan: errChan,
+	}
+}
+
+func (r *ConsumerRunner) Start() error {
+	err := r.handle.Subscribe(r.streams...)
+	if err != nil {
+		return err
+	}
+	logger.Println("Started")
+	return nil
+}
+
+func (r *ConsumerRunner) Stop() {
+	logger.Println("Stopping")
+	r.handle.Close()
+	logger.Println("Stopped")
+}
+
+func (r *ConsumerRunner) Err() <-chan error {
+	return r.errChan
+}
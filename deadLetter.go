@@ -0,0 +1,50 @@
+package redis
+
+import (
+	redis "github.com/go-redis/redis/v7"
+)
+
+// DeadLetterMessageHandler returns a MessageHandleProc, to be used as the
+// UnhandledMessageHandler, which moves the unhandled messages to
+// deadLetterStream. A dead letter keeps the fields of the message and adds
+// FIELD_SOURCE_STREAM and FIELD_SOURCE_ID, which is the format the
+// replay-dead-letters command of redis-stream expects.
+//
+// The dead letter is written and the message acknowledged in one lua
+// script; if the streams are located in different cluster slots, the dead
+// letter is written first and the message acknowledged then. On failure the
+// message stays pending.
+func DeadLetterMessageHandler(deadLetterStream string) MessageHandleProc {
+	return func(ctx *ConsumeContext, stream string, message *XMessage) {
+		values := make(map[string]interface{}, len(message.Values)+2)
+		for k, v := range message.Values {
+			values[k] = v
+		}
+		values[FIELD_SOURCE_STREAM] = stream
+		values[FIELD_SOURCE_ID] = message.ID
+
+		_, err := ctx.ForwardAndAck(deadLetterStream, values)
+		if err != nil {
+			switch err.(type) {
+			case *CrossSlotError:
+				err = writeDeadLetterThenAck(ctx, deadLetterStream, stream, message, values)
+			}
+		}
+		if err != nil && err != ErrMessageNotPending {
+			logger.Printf("%% Warning: cannot move message %s of %s to dead letter stream %s: %v\n", message.ID, stream, deadLetterStream, err)
+		}
+	}
+}
+
+func writeDeadLetterThenAck(ctx *ConsumeContext, deadLetterStream string, stream string, message *XMessage, values map[string]interface{}) error {
+	err := ctx.Handle().XAdd(&redis.XAddArgs{
+		Stream: deadLetterStream,
+		ID:     StreamAsteriskID,
+		Values: values,
+	}).Err()
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Ack(stream, message.ID)
+	return err
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+type DeduplicationOption struct {
+	Window time.Duration // 已處理訊息的記錄保留時間, 預設為 DEFAULT_DEDUPLICATION_WINDOW
+}
+
+func (opt *DeduplicationOption) window() time.Duration {
+	if opt.Window > 0 {
+		return opt.Window
+	}
+	return DEFAULT_DEDUPLICATION_WINDOW
+}
+
+// deduplicate skips the messages recorded as processed. A message is
+// identified by its FIELD_IDEMPOTENCY_KEY, or by its ID if it has none; it
+// is recorded together with its acknowledgement through the ConsumeContext.
+func (c *Consumer) deduplicate(next MessageHandleProc) MessageHandleProc {
+	return func(ctx *ConsumeContext, stream string, message *XMessage) {
+		key := c.processedKey(stream, message)
+
+		processed, err := c.getRedisClient().Exists(key).Result()
+		if err != nil {
+			// handle the message anyway; duplicates are preferred over losses
+			logger.Printf("%% Warning: cannot check whether message %s on %s is processed: %v\n", message.ID, stream, err)
+		}
+		if processed > 0 {
+			if _, err := c.handle.Ack(stream, message.ID); err != nil {
+				logger.Printf("%% Warning: cannot ack duplicated message %s on %s: %v\n", message.ID, stream, err)
+			}
+			return
+		}
+
+		ctx.processedKey = key
+		next(ctx, stream, message)
+	}
+}
+
+func (c *Consumer) processedKey(stream string, message *XMessage) string {
+	id, ok := message.Values[FIELD_IDEMPOTENCY_KEY].(string)
+	if !ok || len(id) == 0 {
+		id = message.ID
+	}
+	return PROCESSED_KEY_PREFIX + internal.HashTag(stream) + ":" + c.Group + ":" + id
+}
+
+// ackProcessed records processedKey and acknowledges ids in one lua script,
+// so a message is never acknowledged without being recorded. processedKey
+// shares the hash tag of stream, which keeps both in one cluster slot.
+func (c *Consumer) ackProcessed(stream, processedKey string, ids ...string) (int64, error) {
+	args := make([]interface{}, 0, 2+len(ids))
+	args = append(args, c.Group, c.Deduplication.window().Milliseconds())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	reply, err := internal.AckProcessedScript.Run(c.getRedisClient(), []string{stream, processedKey}, args...).Int64()
+	if err != nil {
+		if err != redis.Nil {
+			return 0, err
+		}
+	}
+	return reply, nil
+}
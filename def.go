@@ -3,6 +3,7 @@ package redis
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/bcowtech/lib-redis-stream/internal"
 	redis "github.com/go-redis/redis/v7"
@@ -22,6 +23,50 @@ const (
 	MAX_PENDING_FETCHING_SIZE         int64 = 512
 	MIN_PENDING_FETCHING_SIZE         int64 = 16
 	PENDING_FETCHING_SIZE_COEFFICIENT int64 = 3
+
+	// reserved message fields
+	RESERVED_FIELD_PREFIX   string = "__"
+	FIELD_CONTENT           string = "__content"
+	FIELD_CONTENT_ENCODING  string = "__content_encoding"
+	FIELD_CLAIM_CHECK       string = "__claim_check"
+	FIELD_CLAIM_CHECK_HASH  string = "__claim_check_hash"
+	FIELD_ENCRYPTION_KEY_ID string = "__encryption_key_id"
+	FIELD_ENCRYPTED_FIELDS  string = "__encrypted_fields"
+	FIELD_SIGNATURE         string = "__signature"
+	FIELD_SIGNATURE_KEY_ID  string = "__signature_key_id"
+	FIELD_IDEMPOTENCY_KEY   string = "__idempotency_key"
+	FIELD_SOURCE_STREAM     string = "__source_stream"
+	FIELD_SOURCE_ID         string = "__source_id"
+
+	BLOB_KEY_PREFIX        string = "__blob:"
+	IDEMPOTENCY_KEY_PREFIX string = "__idempotency:"
+	PROCESSED_KEY_PREFIX   string = "__processed:"
+	CHECKPOINT_KEY_PREFIX  string = "__checkpoint:"
+
+	EXPORT_FORMAT_VERSION int = 1
+
+	DEFAULT_REDIS_PORT    string = "6379"
+	DEFAULT_SENTINEL_PORT string = "26379"
+
+	DEFAULT_MAX_IN_FLIGHT             int64         = 8
+	DEFAULT_MAX_POLLING_TIMEOUT       time.Duration = time.Second
+	DEFAULT_CLAIM_MIN_IDLE_TIME       time.Duration = 30 * time.Second
+	DEFAULT_IDLING_TIMEOUT            time.Duration = 100 * time.Millisecond
+	DEFAULT_CLAIM_SENSITIVITY         int           = 1
+	DEFAULT_CLAIM_OCCURRENCE_RATE     int32         = 5
+	DEFAULT_IDEMPOTENCY_WINDOW        time.Duration = 24 * time.Hour
+	DEFAULT_IDEMPOTENT_WRITE_ATTEMPTS int           = 3
+	DEFAULT_DEDUPLICATION_WINDOW      time.Duration = 24 * time.Hour
+	DEFAULT_MIRROR_BATCH_SIZE         int64         = 100
+	DEFAULT_MIRROR_POLLING_TIMEOUT    time.Duration = time.Second
+	DEFAULT_MERGE_WATERMARK_DELAY     time.Duration = time.Second
+	DEFAULT_MERGE_BATCH_SIZE          int64         = 100
+	DEFAULT_MERGE_POLLING_INTERVAL    time.Duration = 100 * time.Millisecond
+	DEFAULT_JANITOR_INTERVAL          time.Duration = time.Minute
+	DEFAULT_RETENTION_INTERVAL        time.Duration = time.Minute
+	DEFAULT_RANGE_PAGE_SIZE           int64         = 1000
+	DEFAULT_RETRY_BACKOFF             time.Duration = time.Second
+	DEFAULT_MAX_WORKER_FAILURES       int           = 5
 )
 
 var (
@@ -39,6 +84,9 @@ type (
 
 // func
 type (
-	RedisErrorHandleProc func(err error) (disposed bool)
-	MessageHandleProc    func(ctx *ConsumeContext, stream string, message *XMessage)
+	RedisErrorHandleProc  func(err error) (disposed bool)
+	MessageHandleProc     func(ctx *ConsumeContext, stream string, message *XMessage)
+	MessageMiddlewareProc func(next MessageHandleProc) MessageHandleProc
+	ForwardTransformProc  func(stream string, message *XMessage) (content map[string]interface{}, ok bool)
+	RouteMatchProc        func(stream string, message *XMessage) bool
 )
@@ -0,0 +1,157 @@
+package redis
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+)
+
+var _ KeyProvider = new(StaticKeyProvider)
+
+// KeyProvider supplies the keys used to encrypt or sign messages. The key
+// ID is written into the message, so messages produced with a retired key
+// can still be read as long as Key returns it.
+type KeyProvider interface {
+	CurrentKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+type StaticKeyProvider struct {
+	CurrentKeyID string
+	Keys         map[string][]byte
+}
+
+func (p *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := p.Key(p.CurrentKeyID)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.CurrentKeyID, key, nil
+}
+
+func (p *StaticKeyProvider) Key(id string) ([]byte, error) {
+	key, ok := p.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", id)
+	}
+	return key, nil
+}
+
+type EncryptionOption struct {
+	KeyProvider KeyProvider // AES-128/192/256 金鑰, 依金鑰長度決定
+	Fields      []string    // 要加密的欄位, 未指定時加密全部欄位; 已壓縮的訊息會加密整個壓縮內容
+}
+
+func (opt *EncryptionOption) encrypt(fields map[string]string) (map[string]string, error) {
+	if opt.KeyProvider == nil {
+		return nil, fmt.Errorf("encryption is enabled but no KeyProvider is configured")
+	}
+	keyID, key, err := opt.KeyProvider.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if _, ok := fields[FIELD_CONTENT]; ok {
+		names = []string{FIELD_CONTENT}
+	} else if len(opt.Fields) > 0 {
+		for _, name := range opt.Fields {
+			if _, ok := fields[name]; ok {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for name := range fields {
+			if !strings.HasPrefix(name, RESERVED_FIELD_PREFIX) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return fields, nil
+	}
+
+	var (
+		result    = make(map[string]string, len(fields)+2)
+		encrypted = make(map[string]string, len(names))
+	)
+	for k, v := range fields {
+		result[k] = v
+	}
+	for _, name := range names {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		result[name] = string(aead.Seal(nonce, nonce, []byte(fields[name]), []byte(name)))
+		encrypted[name] = ""
+	}
+	result[FIELD_ENCRYPTION_KEY_ID] = keyID
+	result[FIELD_ENCRYPTED_FIELDS] = string(internal.EncodeFields(encrypted))
+	return result, nil
+}
+
+func decryptMessage(keyProvider KeyProvider, message *XMessage) error {
+	ref, ok := message.Values[FIELD_ENCRYPTION_KEY_ID]
+	if !ok {
+		return nil
+	}
+	if keyProvider == nil {
+		return fmt.Errorf("message is encrypted but no KeyProvider is configured")
+	}
+
+	keyID, _ := ref.(string)
+	key, err := keyProvider.Key(keyID)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	encryptedFields, _ := message.Values[FIELD_ENCRYPTED_FIELDS].(string)
+	encrypted, err := internal.DecodeFields([]byte(encryptedFields))
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]interface{}, len(message.Values))
+	for k, v := range message.Values {
+		if k == FIELD_ENCRYPTION_KEY_ID || k == FIELD_ENCRYPTED_FIELDS {
+			continue
+		}
+		values[k] = v
+	}
+	for name := range encrypted {
+		ciphertext, _ := message.Values[name].(string)
+		if len(ciphertext) < aead.NonceSize() {
+			return fmt.Errorf("malformed encrypted field %q", name)
+		}
+		nonce := []byte(ciphertext[:aead.NonceSize()])
+		plaintext, err := aead.Open(nil, nonce, []byte(ciphertext[aead.NonceSize():]), []byte(name))
+		if err != nil {
+			return fmt.Errorf("cannot decrypt field %q: %v", name, err)
+		}
+		values[name] = string(plaintext)
+	}
+	message.Values = values
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
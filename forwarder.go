@@ -1,7 +1,29 @@
 package redis
 
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
 type Forwarder struct {
 	*Producer
+
+	Source    *Consumer            // 來源 consumer group 的設定; MessageHandler 由 Forwarder 指定, 未指定 RedisOption 時使用 Forwarder 的 redis
+	Streams   []StreamOffset       // 來源 stream
+	Targets   []string             // 目的 stream
+	Transform ForwardTransformProc // 轉換或過濾訊息, 可不指定
+	Router    *Router              // 依訊息內容決定目的 stream, 指定時不使用 Targets
+	Mirror    *MirrorOption        // 指定時改為複製 Streams 到 Forwarder 的 redis, 不使用 Source 及 Transform
+	Merge     *MergeOption         // 指定時改為依 ID 時間合併 Streams 到 Targets[0], 不使用 Source 及 Transform
+
+	redisOption  *UniversalOptions
+	sharedRedis  bool // Source 與 Forwarder 使用同一個 redis, 可在同一個 lua script 中寫入並 Ack
+	sourceHandle UniversalClient
+	sourceMutex  sync.Mutex
+	fatalHandler func(err error)
+	stopChan     chan bool
+	wg           sync.WaitGroup
 }
 
 func NewForwarder(opt *UniversalOptions) (*Forwarder, error) {
@@ -10,13 +32,216 @@ func NewForwarder(opt *UniversalOptions) (*Forwarder, error) {
 		return nil, err
 	}
 	instance := &Forwarder{
-		Producer: producer,
+		Producer:    producer,
+		redisOption: opt,
 	}
 	return instance, nil
 }
 
 func (f *Forwarder) Runner() *ForwarderRunner {
+	errChan, fail := runnerErrorChan()
+	f.fatalHandler = fail
+
 	return &ForwarderRunner{
-		handle: f,
+		handle:  f,
+		errChan: errChan,
+	}
+}
+
+func (f *Forwarder) start() error {
+	if len(f.Streams) == 0 {
+		// nothing to forward; the Forwarder is used as a Producer only
+		return nil
+	}
+	if f.Mirror != nil {
+		return f.startMirror()
+	}
+	if f.Merge != nil {
+		return f.startMerge()
+	}
+	if f.Source == nil {
+		return fmt.Errorf("the Forwarder.Source is not specified")
+	}
+	if len(f.Targets) == 0 && f.Router == nil {
+		return fmt.Errorf("the Forwarder.Targets or Forwarder.Router is not specified")
+	}
+	if f.Router != nil && len(f.Router.DefaultTarget) == 0 && f.Source.UnhandledMessageHandler == nil {
+		return fmt.Errorf("the Forwarder.Router.DefaultTarget or Forwarder.Source.UnhandledMessageHandler must be specified for unroutable messages")
+	}
+
+	if f.Source.RedisOption == nil {
+		f.Source.RedisOption = f.redisOption
+	}
+	f.sharedRedis = f.Source.RedisOption == f.redisOption
+	f.Source.MessageHandler = f.forward
+	f.Source.fatalHandler = f.fatalHandler
+
+	return f.Source.Subscribe(f.Streams...)
+}
+
+func (f *Forwarder) stop() {
+	if f.Mirror != nil || f.Merge != nil {
+		f.stopWorker()
+		return
+	}
+	if len(f.Streams) > 0 && f.Source != nil {
+		f.Source.Close()
+	}
+}
+
+// runWorker calls proc repeatedly in background until the Forwarder is
+// stopped; it is used by the modes which do not read from a consumer group.
+// Failures are retried; after DEFAULT_MAX_WORKER_FAILURES consecutive ones
+// the worker stops and reports the error to the ForwarderRunner, if any.
+func (f *Forwarder) runWorker(proc func() error) {
+	f.stopChan = make(chan bool)
+
+	f.wg.Add(1)
+	go func(stop chan bool) {
+		defer f.wg.Done()
+
+		var failures int
+		for {
+			select {
+			case <-stop:
+				return
+
+			default:
+				err := proc()
+				if err == nil {
+					failures = 0
+					continue
+				}
+
+				failures++
+				if failures >= DEFAULT_MAX_WORKER_FAILURES && f.fatalHandler != nil {
+					f.fatalHandler(fmt.Errorf("gave up after %d consecutive failures: %v", failures, err))
+					return
+				}
+				logger.Printf("%% Error: %v\n", err)
+				select {
+				case <-stop:
+					return
+				case <-time.After(DEFAULT_RETRY_BACKOFF):
+				}
+			}
+		}
+	}(f.stopChan)
+}
+
+func (f *Forwarder) stopWorker() {
+	if f.stopChan != nil {
+		close(f.stopChan)
+		f.wg.Wait()
+		f.stopChan = nil
+	}
+
+	f.sourceMutex.Lock()
+	defer f.sourceMutex.Unlock()
+
+	if f.sourceHandle != nil {
+		f.sourceHandle.Close()
+		f.sourceHandle = nil
+	}
+}
+
+func (f *Forwarder) forward(ctx *ConsumeContext, stream string, message *XMessage) {
+	var content = message.Values
+	if f.Transform != nil {
+		var ok bool
+		content, ok = f.Transform(stream, message)
+		if !ok {
+			// filtered
+			if _, err := ctx.Ack(stream, message.ID); err != nil {
+				logger.Printf("%% Warning: cannot ack message %s on %s: %v\n", message.ID, stream, err)
+			}
+			return
+		}
+	}
+
+	var targets = f.Targets
+	if f.Router != nil {
+		var drop bool
+		targets, drop = f.Router.route(stream, &XMessage{ID: message.ID, Values: content})
+		if drop {
+			if _, err := ctx.Ack(stream, message.ID); err != nil {
+				logger.Printf("%% Warning: cannot ack message %s on %s: %v\n", message.ID, stream, err)
+			}
+			return
+		}
+		if len(targets) == 0 {
+			if f.Source.UnhandledMessageHandler == nil {
+				// the DefaultTarget refers to missing fields; nothing can
+				// take the message, so keeping it pending only retries forever
+				logger.Printf("%% Warning: drop unroutable message %s on %s\n", message.ID, stream)
+				if _, err := ctx.Ack(stream, message.ID); err != nil {
+					logger.Printf("%% Warning: cannot ack message %s on %s: %v\n", message.ID, stream, err)
+				}
+				return
+			}
+			ctx.ForwardUnhandledMessage(stream, message)
+			return
+		}
+	}
+
+	err := f.writeAndAck(ctx, stream, message, targets, content)
+	if err != nil {
+		logger.Printf("%% Warning: cannot forward message %s on %s: %v\n", message.ID, stream, err)
+	}
+}
+
+// writeAndAck writes content to targets and acks the source message in one
+// lua script. If the Source uses another redis, or the streams are located
+// in different cluster slots, it writes the targets first and then acks the
+// source message, which might produce duplicates on failure but never loses
+// the message.
+func (f *Forwarder) writeAndAck(ctx *ConsumeContext, stream string, message *XMessage, targets []string, content map[string]interface{}) error {
+	if !f.sharedRedis {
+		return f.writeThenAck(ctx, stream, message, targets, content)
+	}
+
+	var (
+		outputs  = make([]*OutgoingMessage, 0, len(targets))
+		blobKeys []string
+	)
+	for _, target := range targets {
+		values, blobKey, err := f.encodeContent(target, content, "")
+		if err != nil {
+			return err
+		}
+		if len(blobKey) > 0 {
+			blobKeys = append(blobKeys, blobKey)
+		}
+		outputs = append(outputs, &OutgoingMessage{
+			Stream: target,
+			Values: values,
+		})
+	}
+
+	_, err := ctx.AckAndWrite(outputs...)
+	if err != nil {
+		if len(blobKeys) > 0 {
+			deleteBlobs(f.blobStore(), blobKeys...)
+		}
+
+		switch err.(type) {
+		case *CrossSlotError:
+			return f.writeThenAck(ctx, stream, message, targets, content)
+		}
+		if err == ErrMessageNotPending {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *Forwarder) writeThenAck(ctx *ConsumeContext, stream string, message *XMessage, targets []string, content map[string]interface{}) error {
+	for _, target := range targets {
+		if _, err := f.Write(target, StreamAsteriskID, content); err != nil {
+			return err
+		}
 	}
+	_, err := ctx.Ack(stream, message.ID)
+	return err
 }
@@ -0,0 +1,218 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+// MergeOption merges the Forwarder.Streams into Forwarder.Targets[0] ordered
+// by the timestamp of their entry IDs. Every merged message carries the
+// FIELD_SOURCE_STREAM and FIELD_SOURCE_ID fields.
+//
+// A message is only merged when every source has a pending message, or when
+// it is older than WatermarkDelay; messages written to a source later than
+// WatermarkDelay behind the clock might be merged out of order.
+type MergeOption struct {
+	WatermarkDelay  time.Duration // 等待其他來源較早訊息的時間, 預設為 DEFAULT_MERGE_WATERMARK_DELAY
+	BatchSize       int64         // 每個來源每次讀取的訊息數, 預設為 DEFAULT_MERGE_BATCH_SIZE
+	PollingInterval time.Duration // 沒有可合併的訊息時等待多久, 預設為 DEFAULT_MERGE_POLLING_INTERVAL
+}
+
+func (opt *MergeOption) watermarkDelay() time.Duration {
+	if opt.WatermarkDelay <= 0 {
+		return DEFAULT_MERGE_WATERMARK_DELAY
+	}
+	return opt.WatermarkDelay
+}
+
+func (opt *MergeOption) batchSize() int64 {
+	if opt.BatchSize <= 0 {
+		return DEFAULT_MERGE_BATCH_SIZE
+	}
+	return opt.BatchSize
+}
+
+func (opt *MergeOption) pollingInterval() time.Duration {
+	if opt.PollingInterval <= 0 {
+		return DEFAULT_MERGE_POLLING_INTERVAL
+	}
+	return opt.PollingInterval
+}
+
+type mergeSource struct {
+	stream    string
+	position  internal.StreamID // 最後一筆已讀取的訊息 ID
+	buffer    []XMessage
+	exhausted bool // 最後一次讀取已到 stream 尾端
+}
+
+func (f *Forwarder) startMerge() error {
+	if len(f.Targets) != 1 {
+		return fmt.Errorf("the Forwarder.Targets must have exactly one stream in merging mode")
+	}
+
+	var (
+		target  = f.Targets[0]
+		sources = make([]*mergeSource, 0, len(f.Streams))
+	)
+
+	// resume from checkpoints
+	checkpoints, err := f.handle.HGetAll(f.mergeCheckpointKey(target)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+	}
+	for _, s := range f.Streams {
+		position, ok := checkpoints[s.Stream]
+		if !ok {
+			switch s.Offset {
+			case "", StreamNeverDeliveredOffset, StreamLastDeliveredID:
+				position, err = lastStreamID(f.handle, s.Stream)
+				if err != nil {
+					return err
+				}
+			default:
+				position = s.Offset
+			}
+		}
+
+		id, err := internal.ParseStreamID(position)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, &mergeSource{
+			stream:   s.Stream,
+			position: id,
+		})
+	}
+
+	f.runWorker(func() error {
+		return f.merge(target, sources)
+	})
+	return nil
+}
+
+func (f *Forwarder) merge(target string, sources []*mergeSource) error {
+	var batchSize = f.Merge.batchSize()
+
+	// fill the drained buffers
+	for _, source := range sources {
+		if len(source.buffer) > 0 {
+			continue
+		}
+
+		messages, err := f.handle.XRangeN(source.stream, source.position.Next().String(), "+", batchSize).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return err
+			}
+		}
+		source.exhausted = int64(len(messages)) < batchSize
+		if len(messages) > 0 {
+			id, err := internal.ParseStreamID(messages[len(messages)-1].ID)
+			if err != nil {
+				return err
+			}
+			source.buffer = messages
+			source.position = id
+		}
+	}
+
+	var (
+		watermark = internal.StreamIDFromTime(time.Now().Add(-f.Merge.watermarkDelay()))
+		offsets   = make([]int, len(sources))
+		lastIDs   = make([]string, len(sources))
+		args      = []interface{}{0}
+		count     int64
+	)
+	for count < batchSize {
+		var (
+			head   = -1
+			headID internal.StreamID
+		)
+		for i, source := range sources {
+			if offsets[i] >= len(source.buffer) {
+				continue
+			}
+			id, err := internal.ParseStreamID(source.buffer[offsets[i]].ID)
+			if err != nil {
+				return err
+			}
+			if head < 0 || id.Compare(headID) < 0 {
+				head, headID = i, id
+			}
+		}
+		if head < 0 {
+			break
+		}
+
+		// a drained source might produce an earlier message later
+		var complete = true
+		for i, source := range sources {
+			if offsets[i] < len(source.buffer) {
+				continue
+			}
+			if !source.exhausted || headID.Compare(watermark) > 0 {
+				complete = false
+				break
+			}
+		}
+		if !complete {
+			break
+		}
+
+		var (
+			source  = sources[head]
+			message = source.buffer[offsets[head]]
+		)
+		args = append(args, StreamAsteriskID, len(message.Values)+2,
+			FIELD_SOURCE_STREAM, source.stream,
+			FIELD_SOURCE_ID, message.ID)
+		for k, v := range message.Values {
+			args = append(args, k, v)
+		}
+		offsets[head]++
+		lastIDs[head] = message.ID
+		count++
+	}
+
+	if count == 0 {
+		select {
+		case <-f.stopChan:
+		case <-time.After(f.Merge.pollingInterval()):
+		}
+		return nil
+	}
+
+	// checkpoint the merged sources in the same script
+	var checkpoints = make([]interface{}, 0, len(sources)*2)
+	for i, source := range sources {
+		if len(lastIDs[i]) > 0 {
+			checkpoints = append(checkpoints, source.stream, lastIDs[i])
+		}
+	}
+	args[0] = len(checkpoints) / 2
+	args = append(args[:1], append(checkpoints, args[1:]...)...)
+
+	err := internal.CheckpointedXAddScript.Run(f.handle,
+		[]string{target, f.mergeCheckpointKey(target)},
+		args...).Err()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+	}
+
+	for i, source := range sources {
+		source.buffer = source.buffer[offsets[i]:]
+	}
+	return nil
+}
+
+func (f *Forwarder) mergeCheckpointKey(target string) string {
+	return CHECKPOINT_KEY_PREFIX + internal.HashTag(target) + ":merge"
+}
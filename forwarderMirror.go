@@ -0,0 +1,267 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+type MirrorOption struct {
+	SourceRedisOption *UniversalOptions // 來源 redis; 目的 redis 為 Forwarder 的 redis
+	PreserveID        bool              // 保留來源訊息的 ID
+	BatchSize         int64             // 每次讀取的訊息數, 預設為 DEFAULT_MIRROR_BATCH_SIZE
+	PollingTimeout    time.Duration     // 沒有新訊息時 XREAD 等待多久, 預設為 DEFAULT_MIRROR_POLLING_TIMEOUT
+}
+
+type MirrorLag struct {
+	Stream       string
+	Target       string
+	SourceLastID string        // 來源最後一筆訊息的 ID
+	CheckpointID string        // 最後一筆已複製的來源訊息 ID
+	Lag          time.Duration // SourceLastID 與 CheckpointID 的時間差
+}
+
+// MirrorLag reports how far each target stream is behind its source. It
+// is only available while the mirroring Forwarder is running.
+func (f *Forwarder) MirrorLag() ([]*MirrorLag, error) {
+	if f.Mirror == nil {
+		return nil, fmt.Errorf("the Forwarder is not in mirroring mode")
+	}
+	f.sourceMutex.Lock()
+	defer f.sourceMutex.Unlock()
+
+	if f.sourceHandle == nil {
+		return nil, fmt.Errorf("the Forwarder is not running")
+	}
+
+	var result = make([]*MirrorLag, 0, len(f.Streams))
+	for i, s := range f.Streams {
+		target := f.targetOf(i, s.Stream)
+
+		lastID, err := lastStreamID(f.sourceHandle, s.Stream)
+		if err != nil {
+			return nil, err
+		}
+		checkpointID, err := f.handle.HGet(f.mirrorCheckpointKey(target), s.Stream).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return nil, err
+			}
+		}
+
+		lag := &MirrorLag{
+			Stream:       s.Stream,
+			Target:       target,
+			SourceLastID: lastID,
+			CheckpointID: checkpointID,
+		}
+		if last, err := internal.ParseStreamID(lastID); err == nil {
+			if checkpoint, err := internal.ParseStreamID(checkpointID); err == nil {
+				if last.Compare(checkpoint) > 0 {
+					lag.Lag = time.Duration(last.Timestamp-checkpoint.Timestamp) * time.Millisecond
+				}
+			}
+		}
+		result = append(result, lag)
+	}
+	return result, nil
+}
+
+func (f *Forwarder) startMirror() error {
+	if f.Mirror.SourceRedisOption == nil {
+		return fmt.Errorf("the Forwarder.Mirror.SourceRedisOption is not specified")
+	}
+	if len(f.Targets) > 0 && len(f.Targets) != len(f.Streams) {
+		return fmt.Errorf("the Forwarder.Targets must be empty or match the Forwarder.Streams in mirroring mode")
+	}
+
+	source, err := internal.CreateRedisUniversalClient(f.Mirror.SourceRedisOption)
+	if err != nil {
+		return err
+	}
+
+	// resume from checkpoints
+	var positions = make([]string, len(f.Streams))
+	for i, s := range f.Streams {
+		target := f.targetOf(i, s.Stream)
+
+		checkpointID, err := f.handle.HGet(f.mirrorCheckpointKey(target), s.Stream).Result()
+		if err != nil {
+			if err != redis.Nil {
+				source.Close()
+				return err
+			}
+		}
+		if len(checkpointID) > 0 {
+			positions[i] = checkpointID
+			continue
+		}
+
+		switch s.Offset {
+		case "", StreamNeverDeliveredOffset, StreamLastDeliveredID:
+			// "$" cannot be used across XREAD calls, resolve it now
+			lastID, err := lastStreamID(source, s.Stream)
+			if err != nil {
+				source.Close()
+				return err
+			}
+			positions[i] = lastID
+		default:
+			positions[i] = s.Offset
+		}
+	}
+
+	// one XREAD cannot read streams in different cluster slots
+	groups := groupStreamsBySlot(source, f.Streams)
+
+	f.sourceMutex.Lock()
+	f.sourceHandle = source
+	f.sourceMutex.Unlock()
+
+	f.runWorker(func() error {
+		return f.mirror(source, groups, positions)
+	})
+	return nil
+}
+
+// mirror copies the new entries of each group of streams. A single group is
+// read with a blocking XREAD; several groups are read without blocking, and
+// mirror waits for the polling timeout only if none of them has new entries.
+func (f *Forwarder) mirror(source UniversalClient, groups [][]int, positions []string) error {
+	var (
+		batchSize      = f.Mirror.BatchSize
+		pollingTimeout = f.Mirror.PollingTimeout
+		block          = time.Duration(-1)
+		read           int
+	)
+	if batchSize <= 0 {
+		batchSize = DEFAULT_MIRROR_BATCH_SIZE
+	}
+	if pollingTimeout <= 0 {
+		pollingTimeout = DEFAULT_MIRROR_POLLING_TIMEOUT
+	}
+	if len(groups) == 1 {
+		block = pollingTimeout
+	}
+
+	for _, group := range groups {
+		var streams = make([]string, 0, len(group)*2)
+		for _, i := range group {
+			streams = append(streams, f.Streams[i].Stream)
+		}
+		for _, i := range group {
+			streams = append(streams, positions[i])
+		}
+
+		reply, err := source.XRead(&redis.XReadArgs{
+			Streams: streams,
+			Count:   batchSize,
+			Block:   block,
+		}).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return err
+			}
+		}
+
+		for _, stream := range reply {
+			if len(stream.Messages) == 0 {
+				continue
+			}
+			read += len(stream.Messages)
+
+			for _, i := range group {
+				s := f.Streams[i]
+				if s.Stream != stream.Stream {
+					continue
+				}
+
+				var (
+					target = f.targetOf(i, s.Stream)
+					lastID = stream.Messages[len(stream.Messages)-1].ID
+					args   = make([]interface{}, 0, 3+len(stream.Messages)*8)
+				)
+				args = append(args, 1, s.Stream, lastID)
+				for _, message := range stream.Messages {
+					id := StreamAsteriskID
+					if f.Mirror.PreserveID {
+						id = message.ID
+					}
+					args = append(args, id, len(message.Values))
+					for k, v := range message.Values {
+						args = append(args, k, v)
+					}
+				}
+
+				err := internal.CheckpointedXAddScript.Run(f.handle,
+					[]string{target, f.mirrorCheckpointKey(target)},
+					args...).Err()
+				if err != nil {
+					if err != redis.Nil {
+						return err
+					}
+				}
+				positions[i] = lastID
+			}
+		}
+	}
+
+	if len(groups) > 1 && read == 0 {
+		time.Sleep(pollingTimeout)
+	}
+	return nil
+}
+
+// groupStreamsBySlot groups the indexes of streams by cluster slot; other
+// clients keep all streams in one group.
+func groupStreamsBySlot(client UniversalClient, streams []StreamOffset) [][]int {
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		var group = make([]int, 0, len(streams))
+		for i := range streams {
+			group = append(group, i)
+		}
+		return [][]int{group}
+	}
+
+	var (
+		groups [][]int
+		slots  = make(map[int]int)
+	)
+	for i, s := range streams {
+		slot := internal.HashSlot(s.Stream)
+		index, ok := slots[slot]
+		if !ok {
+			index = len(groups)
+			slots[slot] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], i)
+	}
+	return groups
+}
+
+func (f *Forwarder) targetOf(index int, stream string) string {
+	if len(f.Targets) == 0 {
+		return stream
+	}
+	return f.Targets[index]
+}
+
+func (f *Forwarder) mirrorCheckpointKey(target string) string {
+	return CHECKPOINT_KEY_PREFIX + internal.HashTag(target) + ":mirror"
+}
+
+func lastStreamID(client UniversalClient, stream string) (string, error) {
+	messages, err := client.XRevRangeN(stream, "+", "-", 1).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return "", err
+		}
+	}
+	if len(messages) == 0 {
+		return "0-0", nil
+	}
+	return messages[0].ID, nil
+}
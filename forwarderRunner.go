@@ -1,15 +1,26 @@
 package redis
 
 type ForwarderRunner struct {
-	handle *Forwarder
+	handle  *Forwarder
+	errChan chan error
 }
 
-func (r *ForwarderRunner) Start() {
+func (r *ForwarderRunner) Start() error {
+	err := r.handle.start()
+	if err != nil {
+		return err
+	}
 	logger.Println("Started")
+	return nil
 }
 
 func (r *ForwarderRunner) Stop() {
 	logger.Println("Stopping")
+	r.handle.stop()
 	r.handle.Close()
 	logger.Println("Stopped")
 }
+
+func (r *ForwarderRunner) Err() <-chan error {
+	return r.errChan
+}
@@ -2,4 +2,9 @@ module github.com/bcowtech/lib-redis-stream
 
 go 1.14
 
-require github.com/go-redis/redis/v7 v7.4.0
+require (
+	github.com/go-redis/redis/v7 v7.4.0
+	github.com/golang/snappy v0.0.4
+	github.com/klauspost/compress v1.12.3
+	gopkg.in/yaml.v3 v3.0.1
+)
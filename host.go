@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Host runs several Runners together. Runners are started in order and
+// stopped in reverse order, when the process receives SIGINT or SIGTERM or
+// any Runner reports a fatal error.
+type Host struct {
+	Runners []Runner
+
+	started  []Runner
+	errChan  chan error
+	stopChan chan bool
+	mutex    sync.Mutex
+}
+
+func NewHost(runners ...Runner) *Host {
+	return &Host{
+		Runners: runners,
+	}
+}
+
+func (h *Host) Add(runner Runner) {
+	h.Runners = append(h.Runners, runner)
+}
+
+// Start starts all Runners. If any of them fails, the started ones are
+// stopped and the error is returned.
+func (h *Host) Start() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.stopChan != nil {
+		logger.Panic("the Host is running")
+	}
+
+	var fail func(err error)
+	h.errChan, fail = runnerErrorChan()
+	h.stopChan = make(chan bool)
+
+	for _, runner := range h.Runners {
+		err := runner.Start()
+		if err != nil {
+			h.stopStarted()
+			return err
+		}
+		h.started = append(h.started, runner)
+
+		// h.stopChan is reset by stopStarted; capture it for the goroutine
+		go func(runner Runner, stop chan bool) {
+			select {
+			case err := <-runner.Err():
+				fail(err)
+			case <-stop:
+			}
+		}(runner, h.stopChan)
+	}
+	return nil
+}
+
+// Stop stops the started Runners in reverse order.
+func (h *Host) Stop() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.stopChan == nil {
+		return
+	}
+	h.stopStarted()
+}
+
+// Err reports the first fatal error of the Runners.
+func (h *Host) Err() <-chan error {
+	return h.errChan
+}
+
+// Run starts all Runners and blocks until the process receives SIGINT or
+// SIGTERM or any Runner reports a fatal error, then stops all Runners. It
+// returns the fatal error, or nil if stopped by a signal.
+func (h *Host) Run() error {
+	err := h.Start()
+	if err != nil {
+		return err
+	}
+
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case <-signals:
+	case err = <-h.errChan:
+		logger.Printf("%% Error: %v\n", err)
+	}
+
+	h.Stop()
+	return err
+}
+
+func (h *Host) stopStarted() {
+	close(h.stopChan)
+	h.stopChan = nil
+	for i := len(h.started) - 1; i >= 0; i-- {
+		h.started[i].Stop()
+	}
+	h.started = nil
+}
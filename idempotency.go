@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+)
+
+// WriteIdempotent writes content to stream at most once per idempotencyKey
+// within the Producer.IdempotencyWindow. Writing with a key already seen
+// returns the stream ID of the first write. If idempotencyKey is empty, a
+// random key is generated, which makes the internal retries on network
+// errors safe.
+//
+// The key is stored in the FIELD_IDEMPOTENCY_KEY field of the message.
+func (p *Producer) WriteIdempotent(stream string, idempotencyKey string, content map[string]interface{}) (string, error) {
+	if p.disposed {
+		return "", fmt.Errorf("the Producer has been disposed")
+	}
+
+	p.wg.Add(1)
+	defer p.wg.Done()
+
+	if len(idempotencyKey) == 0 {
+		key, err := internal.GenerateRandomID()
+		if err != nil {
+			return "", err
+		}
+		idempotencyKey = key
+	}
+
+	values, blobKey, err := p.encodeContent(stream, content, idempotencyKey)
+	if err != nil {
+		return "", err
+	}
+
+	var window = p.IdempotencyWindow
+	if window <= 0 {
+		window = DEFAULT_IDEMPOTENCY_WINDOW
+	}
+
+	var (
+		keys = []string{
+			IDEMPOTENCY_KEY_PREFIX + internal.HashTag(stream) + ":" + idempotencyKey,
+			stream,
+		}
+		args = make([]interface{}, 0, 2+len(values)*2)
+	)
+	args = append(args, window.Milliseconds(), StreamAsteriskID)
+	for k, v := range values {
+		args = append(args, k, v)
+	}
+
+	var reply interface{}
+	for attempt := 1; ; attempt++ {
+		reply, err = internal.IdempotentXAddScript.Run(p.handle, keys, args...).Result()
+		if err == nil || attempt >= DEFAULT_IDEMPOTENT_WRITE_ATTEMPTS || !isNetworkError(err) {
+			break
+		}
+	}
+	if err != nil {
+		if len(blobKey) > 0 {
+			deleteBlobs(p.blobStore(), blobKey)
+		}
+		return "", err
+	}
+
+	result, _ := reply.([]interface{})
+	if len(result) != 2 {
+		return "", fmt.Errorf("unexpected reply %v", reply)
+	}
+	id, _ := result[0].(string)
+	duplicated, _ := result[1].(int64)
+	if duplicated == 1 && len(blobKey) > 0 {
+		deleteBlobs(p.blobStore(), blobKey)
+	}
+	return id, nil
+}
+
+func isNetworkError(err error) bool {
+	if err == io.EOF {
+		return true
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
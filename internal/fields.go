@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FormatValue converts v into the string stored by redis, following the same
+// rules go-redis applies when it writes command arguments.
+func FormatValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("can't marshal %T (implement encoding.BinaryMarshaler)", v)
+	}
+}
+
+func NormalizeValues(values map[string]interface{}) (map[string]string, error) {
+	var fields = make(map[string]string, len(values))
+	for k, v := range values {
+		s, err := FormatValue(v)
+		if err != nil {
+			return nil, err
+		}
+		fields[k] = s
+	}
+	return fields, nil
+}
+
+func FieldsSize(fields map[string]string) int {
+	var size int = 0
+	for k, v := range fields {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+func SortedFieldNames(fields map[string]string) []string {
+	var names = make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EncodeFields packs fields into a binary-safe byte sequence. Each field is
+// written as <uvarint len(name)><name><uvarint len(value)><value>, ordered
+// by name so the same fields always produce the same bytes.
+func EncodeFields(fields map[string]string) []byte {
+	var (
+		buf    = make([]byte, 0, FieldsSize(fields)+len(fields)*2*binary.MaxVarintLen32)
+		varint [binary.MaxVarintLen64]byte
+	)
+
+	for _, name := range SortedFieldNames(fields) {
+		value := fields[name]
+
+		n := binary.PutUvarint(varint[:], uint64(len(name)))
+		buf = append(buf, varint[:n]...)
+		buf = append(buf, name...)
+
+		n = binary.PutUvarint(varint[:], uint64(len(value)))
+		buf = append(buf, varint[:n]...)
+		buf = append(buf, value...)
+	}
+	return buf
+}
+
+func DecodeFields(data []byte) (map[string]string, error) {
+	var fields = make(map[string]string)
+
+	read := func() (string, error) {
+		size, n := binary.Uvarint(data)
+		if n <= 0 {
+			return "", fmt.Errorf("malformed fields encoding")
+		}
+		data = data[n:]
+		if uint64(len(data)) < size {
+			return "", fmt.Errorf("malformed fields encoding")
+		}
+		s := string(data[:size])
+		data = data[size:]
+		return s, nil
+	}
+
+	for len(data) > 0 {
+		name, err := read()
+		if err != nil {
+			return nil, err
+		}
+		value, err := read()
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+	return fields, nil
+}
+
+func ToValues(fields map[string]string) map[string]interface{} {
+	var values = make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		values[k] = v
+	}
+	return values
+}
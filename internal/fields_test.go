@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeFields(t *testing.T) {
+	fields := map[string]string{
+		"name":   "luffy",
+		"age":    "19",
+		"empty":  "",
+		"binary": "\x00\xff\r\n",
+	}
+
+	data := EncodeFields(fields)
+
+	// assert
+	{
+		decoded, err := DecodeFields(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(fields, decoded) {
+			t.Errorf("expect %v, but got %v", fields, decoded)
+		}
+	}
+	{
+		_, err := DecodeFields(data[:len(data)-1])
+		if err == nil {
+			t.Errorf("expect error on truncated data")
+		}
+	}
+}
+
+func TestNormalizeValues(t *testing.T) {
+	fields, err := NormalizeValues(map[string]interface{}{
+		"int":   19,
+		"float": 1.5,
+		"bool":  true,
+		"bytes": []byte("nami"),
+		"nil":   nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert
+	{
+		expected := map[string]string{
+			"int":   "19",
+			"float": "1.5",
+			"bool":  "1",
+			"bytes": "nami",
+			"nil":   "",
+		}
+		if !reflect.DeepEqual(expected, fields) {
+			t.Errorf("expect %v, but got %v", expected, fields)
+		}
+	}
+}
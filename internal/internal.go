@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 
 	redis "github.com/go-redis/redis/v7"
@@ -26,3 +28,12 @@ func Assert(ok bool, message string) {
 		panic(message)
 	}
 }
+
+func GenerateRandomID() (string, error) {
+	var buf [16]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	slotTags     [CLUSTER_SLOTS]string
+	slotTagsOnce sync.Once
+)
+
+// HashTag returns a cluster hash tag, in the form "{tag}", for key. Keys
+// which contain the returned tag, after a prefix without braces, are
+// located in the same cluster slot as key.
+func HashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start : start+end+2]
+		}
+	}
+	// redis hashes the whole key; it can be wrapped in braces unless a '}'
+	// would end the tag early, e.g. "a}b" or "empty:{}:x"
+	if strings.IndexByte(key, '}') < 0 {
+		return "{" + key + "}"
+	}
+	return "{" + slotTag(HashSlot(key)) + "}"
+}
+
+// slotTag returns a tag which is hashed to slot.
+func slotTag(slot int) string {
+	slotTagsOnce.Do(func() {
+		for i, n := 0, 0; n < CLUSTER_SLOTS; i++ {
+			tag := strconv.Itoa(i)
+			if s := HashSlot(tag); len(slotTags[s]) == 0 {
+				slotTags[s] = tag
+				n++
+			}
+		}
+	})
+	return slotTags[slot]
+}
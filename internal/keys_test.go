@@ -0,0 +1,46 @@
+package internal
+
+import "testing"
+
+func TestHashTag(t *testing.T) {
+	keys := []string{
+		"orders",
+		"{orders}:1",
+		"tenant:{42}:x",
+		"empty:{}:x",
+		"open:{x",
+		"a}b",
+		"}",
+		"{}",
+		"{a}}b",
+		"x}{y}",
+		"foo{}{bar}",
+		"foo{{bar}}zap",
+	}
+	for _, key := range keys {
+		for _, prefix := range []string{"__idempotency:", "__processed:", "__checkpoint:"} {
+			derived := prefix + HashTag(key) + ":suffix"
+			if HashSlot(derived) != HashSlot(key) {
+				t.Errorf("expect %q in the slot of %q (%d), but got %d", derived, key, HashSlot(key), HashSlot(derived))
+			}
+		}
+	}
+}
+
+func TestHashSlot(t *testing.T) {
+	cases := map[string]int{
+		"123456789":     12739,
+		"foo":           12182,
+		"{user1000}.a":  3443,
+		"{user1000}.b":  3443,
+		"foo{}{bar}":    8363,
+		"foo{{bar}}zap": 4015,
+		"foo{bar}{zap}": 5061,
+	}
+
+	for key, expected := range cases {
+		if slot := HashSlot(key); slot != expected {
+			t.Errorf("expect hash slot of %q to be %d, but got %d", key, expected, slot)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	redis "github.com/go-redis/redis/v7"
+)
+
+var (
+	// KEYS[1] idempotency key, KEYS[2] stream
+	// ARGV[1] window (ms), ARGV[2] id, ARGV[3..] field value ...
+	// returns { id, duplicated (0|1) }
+	IdempotentXAddScript = redis.NewScript(`
+local id = redis.call('GET', KEYS[1])
+if id then
+	return { id, 1 }
+end
+id = redis.call('XADD', KEYS[2], ARGV[2], unpack(ARGV, 3))
+redis.call('SET', KEYS[1], id, 'PX', ARGV[1])
+return { id, 0 }
+`)
+
+	// KEYS[1] source stream, KEYS[2..n] output streams, KEYS[n+1] processed key (optional)
+	// ARGV[1] group, ARGV[2] message id, ARGV[3] processed key window (ms, 0 if absent)
+	// ARGV[4..] for each output: field count, field, value, ...
+	// returns output ids, or nil if the message is not pending
+	//
+	// redis does not roll back a failed script, so the outputs are checked
+	// before anything is written and the message is acknowledged last.
+	AckAndWriteScript = redis.NewScript(`
+if #redis.call('XPENDING', KEYS[1], ARGV[1], ARGV[2], ARGV[2], 1) == 0 then
+	return false
+end
+local outputs = #KEYS - 1
+if tonumber(ARGV[3]) > 0 then
+	outputs = outputs - 1
+end
+for i = 1, outputs do
+	local t = redis.call('TYPE', KEYS[i + 1])
+	t = t.ok or t
+	if t ~= 'stream' and t ~= 'none' then
+		return redis.error_reply('WRONGTYPE ' .. KEYS[i + 1] .. ' holds a ' .. t .. ', not a stream')
+	end
+end
+local ids = {}
+local pos = 4
+for i = 1, outputs do
+	local n = tonumber(ARGV[pos])
+	ids[i] = redis.call('XADD', KEYS[i + 1], '*', unpack(ARGV, pos + 1, pos + n * 2))
+	pos = pos + n * 2 + 1
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[#KEYS], 1, 'PX', ARGV[3])
+end
+redis.call('XACK', KEYS[1], ARGV[1], ARGV[2])
+return ids
+`)
+
+	// KEYS[1] stream, KEYS[2] processed key
+	// ARGV[1] group, ARGV[2] processed key window (ms), ARGV[3..] message ids
+	// returns the number of acknowledged messages
+	AckProcessedScript = redis.NewScript(`
+redis.call('SET', KEYS[2], 1, 'PX', ARGV[2])
+return redis.call('XACK', KEYS[1], ARGV[1], unpack(ARGV, 3))
+`)
+
+	// KEYS[1] target stream, KEYS[2] checkpoint hash
+	// ARGV[1] checkpoint count k, ARGV[2..2k+1] checkpoint field, value, ...
+	// ARGV[2k+2..] for each message: id, field count, field, value, ...
+	// returns the id of the last message written
+	//
+	// explicit ids which are not after the top entry of the target are
+	// skipped; they were written by a previous run which failed before the
+	// checkpoint was saved.
+	CheckpointedXAddScript = redis.NewScript(`
+local function after(a, b)
+	local ams, aseq = string.match(a, '^(%d+)-(%d+)$')
+	local bms, bseq = string.match(b, '^(%d+)-(%d+)$')
+	if ams ~= bms then
+		return #ams > #bms or (#ams == #bms and ams > bms)
+	end
+	return #aseq > #bseq or (#aseq == #bseq and aseq > bseq)
+end
+local k = tonumber(ARGV[1])
+local pos = 2 + k * 2
+local last = false
+local top = redis.call('XREVRANGE', KEYS[1], '+', '-', 'COUNT', 1)[1]
+while pos <= #ARGV do
+	local n = tonumber(ARGV[pos + 1])
+	if ARGV[pos] == '*' or top == nil or after(ARGV[pos], top[1]) then
+		last = redis.call('XADD', KEYS[1], ARGV[pos], unpack(ARGV, pos + 2, pos + 1 + n * 2))
+	end
+	pos = pos + 2 + n * 2
+end
+if k > 0 then
+	redis.call('HSET', KEYS[2], unpack(ARGV, 2, 1 + k * 2))
+end
+return last
+`)
+)
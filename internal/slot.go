@@ -0,0 +1,33 @@
+package internal
+
+import "strings"
+
+const (
+	CLUSTER_SLOTS = 16384
+)
+
+// HashSlot returns the redis cluster slot of key.
+func HashSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % CLUSTER_SLOTS)
+}
+
+// crc16 implements CRC16-CCITT (XMODEM), the checksum used by redis cluster.
+func crc16(key string) uint16 {
+	var crc uint16 = 0
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc = crc << 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type StreamID struct {
+	Timestamp int64 // milliseconds
+	Sequence  uint64
+}
+
+func ParseStreamID(id string) (StreamID, error) {
+	var (
+		result StreamID
+		err    error
+	)
+
+	timestamp, sequence := id, "0"
+	if i := strings.IndexByte(id, '-'); i >= 0 {
+		timestamp, sequence = id[:i], id[i+1:]
+	}
+	result.Timestamp, err = strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return StreamID{}, fmt.Errorf("invalid stream ID %q", id)
+	}
+	result.Sequence, err = strconv.ParseUint(sequence, 10, 64)
+	if err != nil {
+		return StreamID{}, fmt.Errorf("invalid stream ID %q", id)
+	}
+	return result, nil
+}
+
+func StreamIDFromTime(t time.Time) StreamID {
+	return StreamID{
+		Timestamp: t.UnixNano() / int64(time.Millisecond),
+	}
+}
+
+func (id StreamID) String() string {
+	return strconv.FormatInt(id.Timestamp, 10) + "-" + strconv.FormatUint(id.Sequence, 10)
+}
+
+func (id StreamID) Time() time.Time {
+	return time.Unix(0, id.Timestamp*int64(time.Millisecond))
+}
+
+func (id StreamID) Compare(other StreamID) int {
+	switch {
+	case id.Timestamp < other.Timestamp:
+		return -1
+	case id.Timestamp > other.Timestamp:
+		return 1
+	case id.Sequence < other.Sequence:
+		return -1
+	case id.Sequence > other.Sequence:
+		return 1
+	}
+	return 0
+}
+
+// Next returns the smallest ID greater than id, used to turn an inclusive
+// range bound into an exclusive one.
+func (id StreamID) Next() StreamID {
+	if id.Sequence == ^uint64(0) {
+		return StreamID{Timestamp: id.Timestamp + 1}
+	}
+	return StreamID{Timestamp: id.Timestamp, Sequence: id.Sequence + 1}
+}
+
+// Prev returns the greatest ID less than id.
+func (id StreamID) Prev() StreamID {
+	if id.Sequence == 0 {
+		return StreamID{Timestamp: id.Timestamp - 1, Sequence: ^uint64(0)}
+	}
+	return StreamID{Timestamp: id.Timestamp, Sequence: id.Sequence - 1}
+}
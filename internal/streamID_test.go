@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStreamID(t *testing.T) {
+	id, err := ParseStreamID("1526919030474-55")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert
+	{
+		if id.Timestamp != 1526919030474 || id.Sequence != 55 {
+			t.Errorf("unexpected stream ID %+v", id)
+		}
+		if id.String() != "1526919030474-55" {
+			t.Errorf("unexpected stream ID %s", id)
+		}
+		if id.Next().String() != "1526919030474-56" {
+			t.Errorf("unexpected next stream ID %s", id.Next())
+		}
+		if id.Prev().String() != "1526919030474-54" {
+			t.Errorf("unexpected previous stream ID %s", id.Prev())
+		}
+		if (StreamID{Timestamp: 1}).Prev().String() != "0-18446744073709551615" {
+			t.Errorf("unexpected previous stream ID %s", (StreamID{Timestamp: 1}).Prev())
+		}
+		if id.Compare(id.Next()) != -1 || id.Next().Compare(id) != 1 || id.Compare(id) != 0 {
+			t.Errorf("unexpected comparison")
+		}
+	}
+
+	{
+		id, err := ParseStreamID("1526919030474")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.Sequence != 0 {
+			t.Errorf("unexpected stream ID %+v", id)
+		}
+	}
+
+	for _, invalid := range []string{"", "-", "abc-1", "1-abc"} {
+		if _, err := ParseStreamID(invalid); err == nil {
+			t.Errorf("expect error on stream ID %q", invalid)
+		}
+	}
+}
+
+func TestStreamIDFromTime(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	id := StreamIDFromTime(at)
+	if !id.Time().Equal(at) {
+		t.Errorf("expect %v, but got %v", at, id.Time())
+	}
+}
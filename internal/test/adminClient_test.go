@@ -0,0 +1,712 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
+)
+
+func TestAdminClient_Info(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	{
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// deliver one message to gotestConsumer
+	{
+		_, err = admin.Handle().XReadGroup(&goredis.XReadGroupArgs{
+			Group:    "gotestGroup",
+			Consumer: "gotestConsumer",
+			Streams:  []string{"gotestStream1", redis.StreamNeverDeliveredOffset},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert
+	{
+		stream, err := admin.StreamInfo("gotestStream1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stream.Length != 3 {
+			t.Errorf("expect %d messages, but got %d messages", 3, stream.Length)
+		}
+
+		group, err := admin.GroupInfo("gotestStream1", "gotestGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if group == nil {
+			t.Fatalf("expect group gotestGroup, but got nil")
+		}
+		if group.Consumers != 1 || group.Pending != 1 {
+			t.Errorf("expect %d consumers and %d pending, but got %d and %d", 1, 1, group.Consumers, group.Pending)
+		}
+		if len(group.LastDeliveredID) == 0 {
+			t.Errorf("expect last-delivered-id, but got empty")
+		}
+
+		missing, err := admin.GroupInfo("gotestStream1", "unknownGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if missing != nil {
+			t.Errorf("expect nil, but got %+v", missing)
+		}
+
+		consumers, err := admin.ConsumersInfo("gotestStream1", "gotestGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(consumers) != 1 || consumers[0].Name != "gotestConsumer" || consumers[0].Pending != 1 {
+			t.Errorf("expect consumer gotestConsumer with %d pending, but got %+v", 1, consumers)
+		}
+	}
+}
+
+func TestAdminClient_GroupLag(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		for _, stream := range []string{"gotestStream1", "gotestStream2"} {
+			_, err = admin.CreateConsumerGroupAndStream(stream, "gotestGroup", redis.StreamZeroOffset)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// produce message
+	{
+		for _, id := range []string{"1000-0", "2000-0", "3000-0"} {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", ID: id, Values: map[string]interface{}{
+				"id": id,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// deliver one message to gotestConsumer
+	{
+		_, err = admin.Handle().XReadGroup(&goredis.XReadGroupArgs{
+			Group:    "gotestGroup",
+			Consumer: "gotestConsumer",
+			Streams:  []string{"gotestStream1", redis.StreamNeverDeliveredOffset},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert
+	{
+		lags, err := admin.GroupLags("gotestGroup", "gotestStream1", "gotestStream2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lags) != 2 {
+			t.Fatalf("expect %d lags, but got %d", 2, len(lags))
+		}
+
+		lag := lags[0]
+		if lag.Lag != 2 || lag.Pending != 1 {
+			t.Errorf("expect lag %d and %d pending, but got %d and %d", 2, 1, lag.Lag, lag.Pending)
+		}
+		if lag.OldestPendingAge <= lag.OldestUndeliveredAge || lag.OldestUndeliveredAge <= 0 {
+			t.Errorf("expect oldest pending older than oldest undelivered, but got %v and %v", lag.OldestPendingAge, lag.OldestUndeliveredAge)
+		}
+
+		lag = lags[1]
+		if lag.Lag != 0 || lag.Pending != 0 || lag.OldestPendingAge != 0 || lag.OldestUndeliveredAge != 0 {
+			t.Errorf("expect no lag on %s, but got %+v", lag.Stream, lag)
+		}
+
+		if _, err := admin.GroupLag("gotestStream1", "unknownGroup"); err == nil {
+			t.Errorf("expect error, but got nil")
+		}
+	}
+}
+
+func TestAdminClient_Range(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+	}
+
+	// produce message
+	var ids []string
+	{
+		for i := 1; i <= 10; i++ {
+			id := fmt.Sprintf("%d-0", i*1000)
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", ID: id, Values: map[string]interface{}{
+				"id": id,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	collect := func(it *redis.RangeIterator, limit int) []string {
+		var result []string
+		for len(result) < limit && it.Next() {
+			result = append(result, it.Message().ID)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	expect := func(name string, expected, actual []string) {
+		if fmt.Sprint(expected) != fmt.Sprint(actual) {
+			t.Errorf("%s: expect %v, but got %v", name, expected, actual)
+		}
+	}
+
+	// assert
+	{
+		expect("forward", ids,
+			collect(admin.Range("gotestStream1", "-", "+", &redis.RangeOption{PageSize: 3}), 100))
+
+		var reversed []string
+		for i := len(ids) - 1; i >= 0; i-- {
+			reversed = append(reversed, ids[i])
+		}
+		expect("reverse", reversed,
+			collect(admin.Range("gotestStream1", "-", "+", &redis.RangeOption{PageSize: 3, Reverse: true}), 100))
+
+		expect("time bounds", ids[2:5],
+			collect(admin.Range("gotestStream1", "-", "+", &redis.RangeOption{
+				PageSize: 2,
+				Since:    time.Unix(3, 0),
+				Until:    time.Unix(5, 0),
+			}), 100))
+
+		it := admin.Range("gotestStream1", "-", "+", &redis.RangeOption{PageSize: 3})
+		expect("first part", ids[:4], collect(it, 4))
+		expect("resumed", ids[4:],
+			collect(admin.Range("gotestStream1", "-", "+", &redis.RangeOption{PageSize: 3, Cursor: it.Cursor()}), 100))
+
+		it = admin.Range("gotestStream1", "-", "+", &redis.RangeOption{PageSize: 3, Reverse: true})
+		expect("reverse first part", reversed[:4], collect(it, 4))
+		expect("reverse resumed", reversed[4:],
+			collect(admin.Range("gotestStream1", "-", "+", &redis.RangeOption{PageSize: 3, Reverse: true, Cursor: it.Cursor()}), 100))
+
+		expect("empty", nil, collect(admin.Range("gotestStream1", "20000", "+", nil), 100))
+	}
+}
+
+func TestAdminClient_Pending(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	var ids []string
+	{
+		for _, name := range []string{"luffy", "nami", "zoro", "usopp"} {
+			id, err := admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	// deliver two messages to each consumer
+	{
+		for _, consumer := range []string{"gotestConsumerA", "gotestConsumerB"} {
+			_, err = admin.Handle().XReadGroup(&goredis.XReadGroupArgs{
+				Group:    "gotestGroup",
+				Consumer: consumer,
+				Streams:  []string{"gotestStream1", redis.StreamNeverDeliveredOffset},
+				Count:    2,
+				Block:    -1,
+			}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		admin.Handle().XDel("gotestStream1", ids[3])
+	}
+
+	// assert
+	{
+		entries, err := admin.ListPending("gotestStream1", "gotestGroup", &redis.PendingFilter{Consumer: "gotestConsumerA"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 || entries[0].ID != ids[0] || entries[1].ID != ids[1] {
+			t.Errorf("expect pending %v, but got %+v", ids[:2], entries)
+		}
+
+		entries, err = admin.ListPendingMessages("gotestStream1", "gotestGroup", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 4 {
+			t.Fatalf("expect %d pending, but got %d", 4, len(entries))
+		}
+		if entries[0].Message == nil || entries[0].Message.Values["name"] != "luffy" {
+			t.Errorf("expect message luffy, but got %+v", entries[0].Message)
+		}
+		if entries[3].Message != nil {
+			t.Errorf("expect deleted message nil, but got %+v", entries[3].Message)
+		}
+
+		reassigned, err := admin.ReassignPending("gotestStream1", "gotestGroup", "gotestConsumerB", 0, ids[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reassigned) != 1 || reassigned[0] != ids[0] {
+			t.Errorf("expect reassigned %v, but got %v", ids[:1], reassigned)
+		}
+
+		entries, err = admin.ListPending("gotestStream1", "gotestGroup", &redis.PendingFilter{Consumer: "gotestConsumerB"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 3 {
+			t.Errorf("expect %d pending on gotestConsumerB, but got %d", 3, len(entries))
+		}
+
+		entries, err = admin.ListPending("gotestStream1", "gotestGroup", &redis.PendingFilter{MinDeliveryCount: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].ID != ids[0] {
+			t.Errorf("expect redelivered %v, but got %+v", ids[:1], entries)
+		}
+
+		acked, err := admin.AckPending("gotestStream1", "gotestGroup", ids[1], ids[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acked != 2 {
+			t.Errorf("expect %d acked, but got %d", 2, acked)
+		}
+
+		entries, err = admin.ListPending("gotestStream1", "gotestGroup", &redis.PendingFilter{Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expect %d pending, but got %d", 1, len(entries))
+		}
+	}
+}
+
+func TestAdminClient_ResetConsumerGroupOffset(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+	}
+
+	// produce message
+	{
+		for i := 1; i <= 10; i++ {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", ID: fmt.Sprintf("%d-0", i*1000), Values: map[string]interface{}{
+				"seq": i,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err = admin.CreateConsumerGroup("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = admin.CreateConsumerGroup("gotestStream1", "gotestGroupFromZero", redis.StreamZeroOffset)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert
+	{
+		tests := []struct {
+			group       string
+			offset      redis.GroupOffset
+			id          string
+			redelivered int64
+			skipped     int64
+		}{
+			{"gotestGroup", redis.OffsetBack(3), "7000-0", 3, 0},
+			{"gotestGroup", redis.OffsetAtTime(time.Unix(5, 0)), "4999-18446744073709551615", 6, 0},
+			{"gotestGroup", redis.OffsetEnd(), "10000-0", 0, 0},
+			{"gotestGroup", redis.OffsetBeginning(), "0-0", 10, 0},
+			{"gotestGroup", redis.OffsetID("2000-0"), "2000-0", 8, 0},
+			{"gotestGroup", redis.OffsetBack(100), "0-0", 10, 0},
+			{"gotestGroupFromZero", redis.OffsetEnd(), "10000-0", 0, 10},
+		}
+		for _, tt := range tests {
+			result, err := admin.ResetConsumerGroupOffset("gotestStream1", tt.group, tt.offset, true)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.offset, err)
+			}
+			if result.ID != tt.id || result.Redelivered != tt.redelivered || result.Skipped != tt.skipped {
+				t.Errorf("%s: expect %s with %d redelivered and %d skipped, but got %s with %d and %d",
+					tt.offset, tt.id, tt.redelivered, tt.skipped, result.ID, result.Redelivered, result.Skipped)
+			}
+			if result.ID != tt.id && result.PreviousID == result.ID {
+				t.Errorf("%s: expect dry run, but the offset is changed", tt.offset)
+			}
+		}
+
+		// pending entries
+		_, err = admin.Handle().XReadGroup(&goredis.XReadGroupArgs{
+			Group:    "gotestGroupFromZero",
+			Consumer: "gotestConsumer",
+			Streams:  []string{"gotestStream1", redis.StreamNeverDeliveredOffset},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := admin.ResetConsumerGroupOffset("gotestStream1", "gotestGroupFromZero", redis.OffsetAgo(time.Hour), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Skipped != 9 {
+			t.Errorf("expect %d skipped, but got %d", 9, result.Skipped)
+		}
+		if result.Pending != 1 || len(result.Warning) == 0 {
+			t.Errorf("expect warning about %d pending, but got %+v", 1, result)
+		}
+	}
+}
+
+func TestAdminClient_ApplyTopology(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestStream3")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestStream3")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = admin.CreateConsumerGroup("gotestStream1", "gotestStaleGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		admin.Handle().Set("gotestStream3", "not a stream", 0)
+	}
+
+	// EnsureConsumerGroup
+	{
+		created, err := admin.EnsureConsumerGroup("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created {
+			t.Errorf("expect existing group, but got created")
+		}
+	}
+
+	spec := &redis.TopologySpec{
+		Streams: []*redis.StreamSpec{
+			{
+				Name: "gotestStream1",
+				Groups: []*redis.GroupSpec{
+					{Name: "gotestGroup"},
+					{Name: "gotestAuditGroup", StartID: redis.StreamZeroOffset},
+				},
+			},
+			{
+				Name: "gotestStream2",
+			},
+			{
+				Name:   "gotestStream3",
+				Groups: []*redis.GroupSpec{{Name: "gotestGroup"}},
+			},
+		},
+	}
+
+	// assert
+	{
+		report, err := admin.ApplyTopology(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(report.CreatedStreams) != "[gotestStream2]" {
+			t.Errorf("expect created streams [gotestStream2], but got %v", report.CreatedStreams)
+		}
+		if fmt.Sprint(report.CreatedGroups) != "[gotestStream1/gotestAuditGroup]" {
+			t.Errorf("expect created groups [gotestStream1/gotestAuditGroup], but got %v", report.CreatedGroups)
+		}
+		if len(report.Differences) != 2 || len(report.PrunedGroups) != 0 {
+			t.Errorf("expect %d differences and no pruned group, but got %v and %v", 2, report.Differences, report.PrunedGroups)
+		}
+
+		groups, err := admin.GroupsInfo("gotestStream2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 0 {
+			t.Errorf("expect no group on gotestStream2, but got %d", len(groups))
+		}
+
+		spec.PruneGroups = true
+		report, err = admin.ApplyTopology(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.CreatedStreams) != 0 || len(report.CreatedGroups) != 0 {
+			t.Errorf("expect nothing created, but got %v and %v", report.CreatedStreams, report.CreatedGroups)
+		}
+		if fmt.Sprint(report.PrunedGroups) != "[gotestStream1/gotestStaleGroup]" {
+			t.Errorf("expect pruned groups [gotestStream1/gotestStaleGroup], but got %v", report.PrunedGroups)
+		}
+	}
+}
+
+func TestAdminClient_PruneConsumers(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	{
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	read := func(consumer string, count int64) {
+		streams, err := admin.Handle().XReadGroup(&goredis.XReadGroupArgs{
+			Group:    "gotestGroup",
+			Consumer: consumer,
+			Streams:  []string{"gotestStream1", redis.StreamNeverDeliveredOffset},
+			Count:    count,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// touch the consumer; some servers only track idle time on XCLAIM
+		for _, message := range streams[0].Messages {
+			_, err = admin.ReassignPending("gotestStream1", "gotestGroup", consumer, 0, message.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	read("gotestDeadConsumer", 2)
+	time.Sleep(100 * time.Millisecond)
+	read("gotestLiveConsumer", 1)
+
+	// assert
+	{
+		pruned, err := admin.PruneConsumers("gotestStream1", "gotestGroup", 50*time.Millisecond, "gotestLiveConsumer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pruned) != 1 || pruned[0].Name != "gotestDeadConsumer" || pruned[0].Claimed != 2 || !pruned[0].Deleted {
+			t.Errorf("expect gotestDeadConsumer pruned with %d claimed, but got %+v", 2, pruned)
+		}
+
+		consumers, err := admin.ConsumersInfo("gotestStream1", "gotestGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(consumers) != 1 || consumers[0].Name != "gotestLiveConsumer" || consumers[0].Pending != 3 {
+			t.Errorf("expect gotestLiveConsumer with %d pending, but got %+v", 3, consumers)
+		}
+	}
+}
+
+func TestAdminClient_ListStreams(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestString")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestString")
+	}
+
+	// produce message
+	{
+		for _, stream := range []string{"gotestStream1", "gotestStream2"} {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: stream, Values: map[string]interface{}{
+				"name": "luffy",
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = admin.Handle().Set("gotestString", "luffy", 0).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert
+	{
+		streams, err := admin.ListStreams("gotest*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedStreams := []string{"gotestStream1", "gotestStream2"}
+		if !reflect.DeepEqual(expectedStreams, streams) {
+			t.Errorf("expect streams %v, but got %v", expectedStreams, streams)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestClaimCheck(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		largeText = strings.Repeat("luffy", 1024)
+		blobKey   string
+	)
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		p.Compression = &redis.CompressionOption{
+			Threshold: 1024,
+		}
+		p.ClaimCheck = &redis.ClaimCheckOption{
+			Threshold: 64,
+			TTL:       time.Minute,
+		}
+
+		_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+			"name": "luffy",
+			"text": largeText,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert stored content
+	{
+		messages, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 {
+			t.Fatalf("expect 1 messages, but got %d messages", len(messages))
+		}
+		if len(messages[0].Values) != 2 {
+			t.Errorf("expect only the claim check fields, but got %v", messages[0].Values)
+		}
+		blobKey, _ = messages[0].Values[redis.FIELD_CLAIM_CHECK].(string)
+		if len(blobKey) == 0 {
+			t.Fatalf("expect claim check reference, but got %v", messages[0].Values)
+		}
+	}
+
+	var received []map[string]interface{}
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		ReleaseBlobOnAck:    true,
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			received = append(received, message.Values)
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if len(received) != 1 {
+			t.Fatalf("expect 1 messages, but got %d messages", len(received))
+		}
+		if received[0]["text"] != largeText || received[0]["name"] != "luffy" {
+			t.Errorf("unexpected resolved message %v", received[0]["name"])
+		}
+
+		exists, err := admin.Handle().Exists(blobKey).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != 0 {
+			t.Errorf("expect blob %s released after ack", blobKey)
+		}
+	}
+}
+
+func TestClaimCheck_BatchAck(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var blobKeys []string
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		p.ClaimCheck = &redis.ClaimCheckOption{
+			Threshold: 1,
+		}
+
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+				"name": name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		messages, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, message := range messages {
+			blobKey, _ := message.Values[redis.FIELD_CLAIM_CHECK].(string)
+			blobKeys = append(blobKeys, blobKey)
+		}
+		defer admin.Handle().Del(blobKeys...)
+	}
+
+	var ids []string
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    time.Minute,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		ReleaseBlobOnAck:    true,
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ids = append(ids, message.ID)
+			switch len(ids) {
+			case 2:
+				// acknowledge the previous messages together
+				ctx.Ack(stream, ids...)
+			case 3:
+				ctx.Del(stream, ids[2])
+			}
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if len(ids) != 3 {
+			t.Fatalf("expect 3 messages, but got %d messages", len(ids))
+		}
+
+		exists, err := admin.Handle().Exists(blobKeys...).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != 0 {
+			t.Errorf("expect %d blobs released, but %d remain", len(blobKeys), exists)
+		}
+	}
+}
+
+func TestFileBlobStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotestBlobStore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &redis.FileBlobStore{Dir: dir}
+
+	err = store.Put("gotestStream1:luffy", []byte("\x00luffy\xff"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put("gotestStream1:nami", []byte("nami"), time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// assert
+	{
+		data, err := store.Get("gotestStream1:luffy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "\x00luffy\xff" {
+			t.Errorf("unexpected blob %q", data)
+		}
+
+		_, err = store.Get("gotestStream1:nami")
+		if err != redis.ErrBlobNotFound {
+			t.Errorf("expect ErrBlobNotFound on expired blob, but got %v", err)
+		}
+
+		err = store.Delete("gotestStream1:luffy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.Get("gotestStream1:luffy")
+		if err != redis.ErrBlobNotFound {
+			t.Errorf("expect ErrBlobNotFound on deleted blob, but got %v", err)
+		}
+	}
+}
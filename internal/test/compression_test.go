@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestCompression(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		largeText = strings.Repeat("luffy", 1024)
+	)
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		p.Compression = &redis.CompressionOption{
+			Compressor: redis.SnappyCompressor,
+			Threshold:  1024,
+		}
+
+		_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+			"name": "luffy",
+			"text": largeText,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+			"name": "nami",
+			"age":  21,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert stored content
+	{
+		messages, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 2 {
+			t.Fatalf("expect 2 messages, but got %d messages", len(messages))
+		}
+		if messages[0].Values[redis.FIELD_CONTENT_ENCODING] != "snappy" {
+			t.Errorf("expect first message compressed, but got %v", messages[0].Values)
+		}
+		if _, ok := messages[1].Values[redis.FIELD_CONTENT_ENCODING]; ok {
+			t.Errorf("expect second message uncompressed, but got %v", messages[1].Values)
+		}
+	}
+
+	var received []map[string]interface{}
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			received = append(received, message.Values)
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if len(received) != 2 {
+			t.Fatalf("expect 2 messages, but got %d messages", len(received))
+		}
+		if received[0]["text"] != largeText || received[0]["name"] != "luffy" {
+			t.Errorf("unexpected decompressed message %v", received[0]["name"])
+		}
+		if received[1]["age"] != "21" {
+			t.Errorf("unexpected message %v", received[1])
+		}
+	}
+}
+
+func TestCompressors(t *testing.T) {
+	data := []byte(strings.Repeat("luffy", 1024))
+
+	for _, compressor := range []redis.Compressor{
+		redis.GzipCompressor,
+		redis.SnappyCompressor,
+		redis.ZstdCompressor,
+	} {
+		compressed, err := compressor.Compress(data)
+		if err != nil {
+			t.Fatalf("%s: %v", compressor.Name(), err)
+		}
+		if len(compressed) >= len(data) {
+			t.Errorf("%s: expect compressed size less than %d, but got %d", compressor.Name(), len(data), len(compressed))
+		}
+		decompressed, err := compressor.Decompress(compressed)
+		if err != nil {
+			t.Fatalf("%s: %v", compressor.Name(), err)
+		}
+		if string(decompressed) != string(data) {
+			t.Errorf("%s: unexpected decompressed content", compressor.Name())
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestParseRedisURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		addrs      []string
+		username   string
+		password   string
+		db         int
+		masterName string
+		tls        bool
+	}{
+		{"redis://localhost", []string{"localhost:6379"}, "", "", 0, "", false},
+		{"redis://:secret@127.0.0.1:6380/2", []string{"127.0.0.1:6380"}, "", "secret", 2, "", false},
+		{"redis://user:secret@h1:7000,h2,h3:7002", []string{"h1:7000", "h2:6379", "h3:7002"}, "user", "secret", 0, "", false},
+		{"rediss://cache.example.com?db=3&pool_size=20", []string{"cache.example.com:6379"}, "", "", 3, "", true},
+		{"redis-sentinel://:secret@s1,s2:26380/mymaster/1", []string{"s1:26379", "s2:26380"}, "", "secret", 1, "mymaster", false},
+	}
+
+	for _, tt := range tests {
+		opt, err := redis.ParseRedisURL(tt.url)
+		if err != nil {
+			t.Errorf("%s: %v", tt.url, err)
+			continue
+		}
+		if len(opt.Addrs) != len(tt.addrs) {
+			t.Errorf("%s: expect addrs %v, but got %v", tt.url, tt.addrs, opt.Addrs)
+			continue
+		}
+		for i := range tt.addrs {
+			if opt.Addrs[i] != tt.addrs[i] {
+				t.Errorf("%s: expect addrs %v, but got %v", tt.url, tt.addrs, opt.Addrs)
+			}
+		}
+		if opt.Username != tt.username || opt.Password != tt.password {
+			t.Errorf("%s: expect credential %s:%s, but got %s:%s", tt.url, tt.username, tt.password, opt.Username, opt.Password)
+		}
+		if opt.DB != tt.db {
+			t.Errorf("%s: expect db %d, but got %d", tt.url, tt.db, opt.DB)
+		}
+		if opt.MasterName != tt.masterName {
+			t.Errorf("%s: expect master name %q, but got %q", tt.url, tt.masterName, opt.MasterName)
+		}
+		if (opt.TLSConfig != nil) != tt.tls {
+			t.Errorf("%s: expect tls %v, but got %v", tt.url, tt.tls, opt.TLSConfig != nil)
+		}
+	}
+
+	// go-redis takes the server name from the address of each node
+	{
+		opt, err := redis.ParseRedisURL("rediss://h1:7000,h2:7001")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opt.TLSConfig == nil || len(opt.TLSConfig.ServerName) != 0 {
+			t.Errorf("expect tls without server name, but got %+v", opt.TLSConfig)
+		}
+	}
+
+	for _, url := range []string{
+		"http://localhost",
+		"redis://",
+		"redis://localhost/abc",
+		"redis://localhost?unknown=1",
+		"redis://localhost?skip_verify=true",
+		"redis://h1,h2/1",
+		"redis-sentinel://s1",
+	} {
+		if _, err := redis.ParseRedisURL(url); err == nil {
+			t.Errorf("%s: expect error, but got nil", url)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"config.yaml": `
+redis:
+  url: redis://localhost:6379/1
+consumer:
+  group: gotestGroup
+  max_in_flight: 16
+  claim_min_idle_time: 5s
+  claim_occurrence_rate: 3
+`,
+		"config.json": `{
+  "redis": {"url": "redis://localhost:6379/1"},
+  "consumer": {"group": "gotestGroup", "max_in_flight": 16, "claim_min_idle_time": "5s", "claim_occurrence_rate": 3}
+}`,
+	}
+
+	os.Setenv("GOTEST_CONSUMER_NAME", "gotestConsumer")
+	os.Setenv("GOTEST_CONSUMER_IDLING_TIMEOUT", "250ms")
+	defer func() {
+		os.Unsetenv("GOTEST_CONSUMER_NAME")
+		os.Unsetenv("GOTEST_CONSUMER_IDLING_TIMEOUT")
+	}()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := redis.LoadConfig(path, "GOTEST")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		c, err := config.NewConsumer()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if c.Group != "gotestGroup" || c.Name != "gotestConsumer" {
+			t.Errorf("%s: expect consumer gotestGroup/gotestConsumer, but got %s/%s", name, c.Group, c.Name)
+		}
+		if c.RedisOption.DB != 1 {
+			t.Errorf("%s: expect db %d, but got %d", name, 1, c.RedisOption.DB)
+		}
+		if c.MaxInFlight != 16 {
+			t.Errorf("%s: expect MaxInFlight %d, but got %d", name, 16, c.MaxInFlight)
+		}
+		if c.ClaimMinIdleTime != 5*time.Second {
+			t.Errorf("%s: expect ClaimMinIdleTime %v, but got %v", name, 5*time.Second, c.ClaimMinIdleTime)
+		}
+		if c.IdlingTimeout != 250*time.Millisecond {
+			t.Errorf("%s: expect IdlingTimeout %v, but got %v", name, 250*time.Millisecond, c.IdlingTimeout)
+		}
+		if c.MaxPollingTimeout != redis.DEFAULT_MAX_POLLING_TIMEOUT {
+			t.Errorf("%s: expect MaxPollingTimeout %v, but got %v", name, redis.DEFAULT_MAX_POLLING_TIMEOUT, c.MaxPollingTimeout)
+		}
+		// the unspecified claim trigger is disabled
+		if c.ClaimSensitivity != 0 || c.ClaimOccurrenceRate != 3 {
+			t.Errorf("%s: expect claim triggers %d/%d, but got %d/%d", name, 0, 3, c.ClaimSensitivity, c.ClaimOccurrenceRate)
+		}
+	}
+
+	// invalid
+	{
+		config := &redis.Config{}
+		if err := config.Validate(); err == nil {
+			t.Errorf("expect error without redis.url, but got nil")
+		}
+
+		config.Redis.URL = "redis://localhost"
+		config.Consumer.MaxInFlight = -1
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "must not be negative") {
+			t.Errorf("expect error with negative max_in_flight, but got %v", err)
+		}
+
+		os.Setenv("GOTEST_CONSUMER_MAX_IN_FLIGHT", "many")
+		defer os.Unsetenv("GOTEST_CONSUMER_MAX_IN_FLIGHT")
+		if _, err := redis.LoadConfig(filepath.Join(dir, "config.yaml"), "GOTEST"); err == nil {
+			t.Errorf("expect error with invalid GOTEST_CONSUMER_MAX_IN_FLIGHT, but got nil")
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestNewConsumer(t *testing.T) {
+	var handler redis.MessageHandleProc = func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {}
+
+	c, err := redis.NewConsumer(
+		redis.WithGroup("gotestGroup"),
+		redis.WithName("gotestConsumer"),
+		redis.WithRedisOption(&redis.UniversalOptions{Addrs: []string{"127.0.0.1:6379"}}),
+		redis.WithMessageHandler(handler),
+		redis.WithMaxInFlight(16),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert
+	{
+		if c.MaxInFlight != 16 {
+			t.Errorf("expect MaxInFlight %d, but got %d", 16, c.MaxInFlight)
+		}
+		if c.MaxPollingTimeout != redis.DEFAULT_MAX_POLLING_TIMEOUT {
+			t.Errorf("expect MaxPollingTimeout %v, but got %v", redis.DEFAULT_MAX_POLLING_TIMEOUT, c.MaxPollingTimeout)
+		}
+		if c.ClaimMinIdleTime != redis.DEFAULT_CLAIM_MIN_IDLE_TIME {
+			t.Errorf("expect ClaimMinIdleTime %v, but got %v", redis.DEFAULT_CLAIM_MIN_IDLE_TIME, c.ClaimMinIdleTime)
+		}
+		if c.IdlingTimeout != redis.DEFAULT_IDLING_TIMEOUT {
+			t.Errorf("expect IdlingTimeout %v, but got %v", redis.DEFAULT_IDLING_TIMEOUT, c.IdlingTimeout)
+		}
+		if c.ClaimSensitivity != redis.DEFAULT_CLAIM_SENSITIVITY || c.ClaimOccurrenceRate != redis.DEFAULT_CLAIM_OCCURRENCE_RATE {
+			t.Errorf("expect claim triggers %d/%d, but got %d/%d",
+				redis.DEFAULT_CLAIM_SENSITIVITY, redis.DEFAULT_CLAIM_OCCURRENCE_RATE,
+				c.ClaimSensitivity, c.ClaimOccurrenceRate)
+		}
+	}
+}
+
+func TestConsumer_Validate(t *testing.T) {
+	var handler redis.MessageHandleProc = func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {}
+
+	valid := func() *redis.Consumer {
+		return &redis.Consumer{
+			Group:          "gotestGroup",
+			Name:           "gotestConsumer",
+			RedisOption:    &redis.UniversalOptions{Addrs: []string{"127.0.0.1:6379"}},
+			MessageHandler: handler,
+		}
+	}
+
+	if err := valid().Validate(); err != nil {
+		t.Errorf("expect no error, but got %v", err)
+	}
+
+	// explicit claim trigger is kept
+	{
+		c := valid()
+		c.ClaimOccurrenceRate = 3
+		if err := c.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if c.ClaimSensitivity != 0 || c.ClaimOccurrenceRate != 3 {
+			t.Errorf("expect claim triggers %d/%d, but got %d/%d", 0, 3, c.ClaimSensitivity, c.ClaimOccurrenceRate)
+		}
+	}
+
+	// subscribing nothing is a no-op, even without settings
+	{
+		c := &redis.Consumer{}
+		if err := c.Subscribe(); err != nil {
+			t.Errorf("expect no error without streams, but got %v", err)
+		}
+	}
+
+	tests := map[string]func(c *redis.Consumer){
+		"no group":                   func(c *redis.Consumer) { c.Group = "" },
+		"no name":                    func(c *redis.Consumer) { c.Name = "" },
+		"no redis option":            func(c *redis.Consumer) { c.RedisOption = nil },
+		"no message handler":         func(c *redis.Consumer) { c.MessageHandler = nil },
+		"negative max in flight":     func(c *redis.Consumer) { c.MaxInFlight = -1 },
+		"negative polling timeout":   func(c *redis.Consumer) { c.MaxPollingTimeout = -time.Second },
+		"negative claim idle time":   func(c *redis.Consumer) { c.ClaimMinIdleTime = -time.Second },
+		"negative idling timeout":    func(c *redis.Consumer) { c.IdlingTimeout = -time.Second },
+		"negative claim sensitivity": func(c *redis.Consumer) { c.ClaimSensitivity = -1 },
+		"negative claim rate":        func(c *redis.Consumer) { c.ClaimOccurrenceRate = -1 },
+		"negative dedupe window": func(c *redis.Consumer) {
+			c.Deduplication = &redis.DeduplicationOption{Window: -time.Second}
+		},
+	}
+	for name, mutate := range tests {
+		c := valid()
+		mutate(c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expect error, but got nil", name)
+		}
+	}
+
+	// Subscribe rejects the invalid Consumer before connecting
+	{
+		c := valid()
+		c.MessageHandler = nil
+		if err := c.Subscribe(redis.FromStreamNeverDeliveredOffset("gotestStream1")); err == nil {
+			t.Errorf("expect error, but got nil")
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
+)
+
+func TestAdminClient_CopyStream(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+	dstOpt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    1,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := redis.NewAdminClient(dstOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		dst.Handle().Del("gotestStream2")
+		admin.Close()
+		dst.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		dst.Handle().Del("gotestStream2")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	var ids []string
+	{
+		for _, name := range []string{"luffy", "nami", "zoro", "usopp", "sanji"} {
+			id, err := admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	// deliver 3 messages and ack one of them
+	{
+		_, err = admin.Handle().XReadGroup(&goredis.XReadGroupArgs{
+			Group:    "gotestGroup",
+			Consumer: "gotestConsumer",
+			Streams:  []string{"gotestStream1", redis.StreamNeverDeliveredOffset},
+			Count:    3,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = admin.AckPending("gotestStream1", "gotestGroup", ids[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := admin.CopyStream("gotestStream1", dst, "gotestStream2", &redis.CopyOption{
+		BatchSize:     2,
+		ReplayPending: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert
+	{
+		if result.Copied != 5 || result.Pending != 2 || len(result.Groups) != 1 {
+			t.Errorf("expect %d copied with %d pending and %d group, but got %+v", 5, 2, 1, result)
+		}
+
+		messages, err := dst.Handle().XRange("gotestStream2", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != len(ids) {
+			t.Fatalf("expect %d messages, but got %d messages", len(ids), len(messages))
+		}
+		for i, message := range messages {
+			if message.ID != ids[i] {
+				t.Errorf("expect ID %s, but got %s", ids[i], message.ID)
+			}
+		}
+
+		group, err := dst.GroupInfo("gotestStream2", "gotestGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if group == nil || group.LastDeliveredID != ids[2] {
+			t.Errorf("expect gotestGroup at %s, but got %+v", ids[2], group)
+		}
+
+		pending, err := dst.ListPending("gotestStream2", "gotestGroup", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 2 || pending[0].ID != ids[1] || pending[0].Consumer != "gotestConsumer" {
+			t.Errorf("expect pending %v on gotestConsumer, but got %+v", ids[1:3], pending)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestDeadLetterMessageHandler(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("{gotest}Stream1", "{gotest}DeadLetter")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("{gotest}Stream1", "{gotest}DeadLetter")
+		_, err = admin.CreateConsumerGroupAndStream("{gotest}Stream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	var ids = make(map[string]string)
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		for _, name := range []string{"luffy", "nami"} {
+			id, err := p.Write("{gotest}Stream1", redis.StreamAsteriskID, map[string]interface{}{
+				"name": name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids[name] = id
+		}
+	}
+
+	c := &redis.Consumer{
+		Group:                   "gotestGroup",
+		Name:                    "gotestConsumer",
+		RedisOption:             opt,
+		MaxInFlight:             8,
+		MaxPollingTimeout:       10 * time.Millisecond,
+		ClaimMinIdleTime:        30 * time.Millisecond,
+		IdlingTimeout:           100 * time.Millisecond,
+		ClaimSensitivity:        2,
+		ClaimOccurrenceRate:     2,
+		UnhandledMessageHandler: redis.DeadLetterMessageHandler("{gotest}DeadLetter"),
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			if message.Values["name"] == "nami" {
+				ctx.ForwardUnhandledMessage(stream, message)
+				return
+			}
+			ctx.Ack(stream, message.ID)
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("{gotest}Stream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		messages, err := admin.Handle().XRange("{gotest}DeadLetter", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 {
+			t.Fatalf("expect %d dead letters, but got %d", 1, len(messages))
+		}
+		expectedValues := map[string]interface{}{
+			"name":                    "nami",
+			redis.FIELD_SOURCE_STREAM: "{gotest}Stream1",
+			redis.FIELD_SOURCE_ID:     ids["nami"],
+		}
+		for k, v := range expectedValues {
+			if messages[0].Values[k] != v {
+				t.Errorf("expect %s '%v' on the dead letter, but got '%v'", k, v, messages[0].Values[k])
+			}
+		}
+
+		pending, err := admin.Handle().XPending("{gotest}Stream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending.Count != 0 {
+			t.Errorf("expect %d pending messages, but got %d", 0, pending.Count)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
+)
+
+func TestDeduplication(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		admin.Close()
+	}()
+
+	var processedKeys = []string{
+		redis.PROCESSED_KEY_PREFIX + "{gotestStream1}:gotestGroup:luffy-order-1",
+	}
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		admin.Handle().Del(processedKeys...)
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer admin.Handle().Del(processedKeys...)
+
+	// produce message; the first one is duplicated by a retry of the client
+	{
+		for _, values := range []map[string]interface{}{
+			{"name": "luffy", redis.FIELD_IDEMPOTENCY_KEY: "luffy-order-1"},
+			{"name": "luffy", redis.FIELD_IDEMPOTENCY_KEY: "luffy-order-1"},
+			{"name": "nami"},
+		} {
+			err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: values}).Err()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var msgCnt int = 0
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         1,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		Deduplication:       &redis.DeduplicationOption{Window: time.Minute},
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			_, err := ctx.AckAndWrite(&redis.OutgoingMessage{
+				Stream: "gotestStream2",
+				Values: map[string]interface{}{
+					"greeting": "hello " + message.Values["name"].(string),
+				},
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			msgCnt++
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		var expectedMsgCnt int = 2
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+
+		outputCnt, err := admin.Handle().XLen("gotestStream2").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outputCnt != 2 {
+			t.Errorf("expect %d output messages, but got %d messages", 2, outputCnt)
+		}
+
+		pending, err := admin.Handle().XPending("gotestStream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending.Count != 0 {
+			t.Errorf("expect no pending messages, but got %d messages", pending.Count)
+		}
+	}
+}
+
+func TestDeduplication_Ack(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	var ids []string
+	{
+		for _, name := range []string{"luffy", "nami"} {
+			id, err := admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	var processedKeys = []string{
+		redis.PROCESSED_KEY_PREFIX + "{gotestStream1}:gotestGroup:" + ids[0],
+		redis.PROCESSED_KEY_PREFIX + "{gotestStream1}:gotestGroup:" + ids[1],
+	}
+	admin.Handle().Del(processedKeys...)
+	defer admin.Handle().Del(processedKeys...)
+
+	// nami is processed, but the consumer crashed before the Ack
+	err = admin.Handle().Set(processedKeys[1], 1, time.Minute).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received []string
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		Deduplication:       &redis.DeduplicationOption{Window: time.Minute},
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			_, err := ctx.Ack(stream, message.ID)
+			if err != nil {
+				t.Error(err)
+			}
+			received = append(received, message.Values["name"].(string))
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if len(received) != 1 || received[0] != "luffy" {
+			t.Errorf("expect messages %v, but got %v", []string{"luffy"}, received)
+		}
+
+		processed, err := admin.Handle().Exists(processedKeys[0]).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if processed != 1 {
+			t.Errorf("expect message %s recorded as processed", ids[0])
+		}
+
+		pending, err := admin.Handle().XPending("gotestStream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending.Count != 0 {
+			t.Errorf("expect no pending messages, but got %d messages", pending.Count)
+		}
+	}
+}
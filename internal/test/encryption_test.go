@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestEncryption(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keyProvider := &redis.StaticKeyProvider{
+		CurrentKeyID: "v1",
+		Keys: map[string][]byte{
+			"v1": []byte("0123456789abcdef"),
+			"v2": []byte("0123456789abcdef0123456789abcdef"),
+		},
+	}
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		p.Encryption = &redis.EncryptionOption{
+			KeyProvider: keyProvider,
+			Fields:      []string{"ssn"},
+		}
+
+		_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+			"name": "luffy",
+			"ssn":  "A123456789",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// rotate key
+		keyProvider.CurrentKeyID = "v2"
+
+		_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+			"name": "nami",
+			"ssn":  "B223456789",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert stored content
+	{
+		messages, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 2 {
+			t.Fatalf("expect 2 messages, but got %d messages", len(messages))
+		}
+		for i, keyID := range []string{"v1", "v2"} {
+			values := messages[i].Values
+			if values[redis.FIELD_ENCRYPTION_KEY_ID] != keyID {
+				t.Errorf("expect key id %s, but got %v", keyID, values[redis.FIELD_ENCRYPTION_KEY_ID])
+			}
+			if values["ssn"] == "A123456789" || values["ssn"] == "B223456789" {
+				t.Errorf("expect field ssn encrypted")
+			}
+		}
+	}
+
+	var received []map[string]interface{}
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		KeyProvider:         keyProvider,
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			received = append(received, message.Values)
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if len(received) != 2 {
+			t.Fatalf("expect 2 messages, but got %d messages", len(received))
+		}
+		if received[0]["ssn"] != "A123456789" || received[1]["ssn"] != "B223456789" {
+			t.Errorf("unexpected decrypted messages %v", received)
+		}
+		if _, ok := received[0][redis.FIELD_ENCRYPTION_KEY_ID]; ok {
+			t.Errorf("expect reserved fields removed, but got %v", received[0])
+		}
+	}
+}
+
+func TestEncryption_NoKeyProvider(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	p, err := redis.NewProducer(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		p.Handle().Del("gotestStream1")
+		p.Close()
+	}()
+
+	p.Encryption = &redis.EncryptionOption{}
+
+	_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+		"name": "luffy",
+	})
+	if err == nil {
+		t.Errorf("expect an error without KeyProvider, but got nil")
+	}
+}
@@ -0,0 +1,266 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
+)
+
+func TestAdminClient_ExportImport(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestStream3")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestStream3")
+	}
+
+	// produce message
+	var ids []string
+	{
+		for _, value := range []string{"luffy", "\xff\x00binary", "zoro"} {
+			id, err := admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": value,
+				"crew": "straw hat",
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		_, err = admin.CreateConsumerGroup("gotestStream1", "gotestGroup", ids[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	count, err := admin.ExportStream("gotestStream1", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("expect %d exported, but got %d", 3, count)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 4 {
+		t.Errorf("expect %d lines, but got %d", 4, lines)
+	}
+	exported := buf.Bytes()
+
+	assertStream := func(stream string, preserveID bool, result *redis.ImportResult) {
+		if result.Imported != 3 {
+			t.Errorf("%s: expect %d imported, but got %d", stream, 3, result.Imported)
+		}
+
+		messages, err := admin.Handle().XRange(stream, "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 3 {
+			t.Fatalf("%s: expect %d messages, but got %d messages", stream, 3, len(messages))
+		}
+		if messages[1].Values["name"] != "\xff\x00binary" || messages[1].Values["crew"] != "straw hat" {
+			t.Errorf("%s: expect binary value kept, but got %q", stream, messages[1].Values)
+		}
+		if preserveID && messages[0].ID != ids[0] {
+			t.Errorf("%s: expect ID %s, but got %s", stream, ids[0], messages[0].ID)
+		}
+
+		group, err := admin.GroupInfo(stream, "gotestGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if group == nil || group.LastDeliveredID != messages[1].ID || result.Groups["gotestGroup"] != messages[1].ID {
+			t.Errorf("%s: expect gotestGroup at %s, but got %+v", stream, messages[1].ID, group)
+		}
+	}
+
+	// assert
+	{
+		result, err := admin.ImportStream(bytes.NewReader(exported), &redis.ImportOption{
+			Stream:        "gotestStream2",
+			PreserveID:    true,
+			RestoreGroups: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertStream("gotestStream2", true, result)
+
+		result, err = admin.ImportStream(bytes.NewReader(exported), &redis.ImportOption{
+			Stream:        "gotestStream3",
+			RestoreGroups: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertStream("gotestStream3", false, result)
+
+		_, err = admin.ImportStream(strings.NewReader("{\"version\":99}\n"), nil)
+		if err == nil {
+			t.Errorf("expect error, but got nil")
+		}
+	}
+}
+
+func TestAdminClient_ImportResume(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+	}
+
+	// produce message
+	var ids []string
+	{
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			id, err := admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	var buf bytes.Buffer
+	_, err = admin.ExportStream("gotestStream1", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a previous import failed after the first entry
+	err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream2", ID: ids[0], Values: map[string]interface{}{
+		"name": "luffy",
+	}}).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := admin.ImportStream(&buf, &redis.ImportOption{
+		Stream:     "gotestStream2",
+		PreserveID: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert
+	{
+		if result.Imported != 2 || result.Skipped != 1 {
+			t.Errorf("expect %d imported and %d skipped, but got %+v", 2, 1, result)
+		}
+
+		messages, err := admin.Handle().XRange("gotestStream2", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != len(ids) {
+			t.Fatalf("expect %d messages, but got %d messages", len(ids), len(messages))
+		}
+		for i, message := range messages {
+			if message.ID != ids[i] {
+				t.Errorf("expect ID %s, but got %s", ids[i], message.ID)
+			}
+		}
+	}
+}
+
+func TestAdminClient_ImportLastGeneratedID(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+	}
+
+	// produce message
+	var ids []string
+	{
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			id, err := admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		// the last-generated-id stays at the deleted entry
+		err = admin.Handle().XDel("gotestStream1", ids[2]).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = admin.Handle().Do("XSETID", "gotestStream1", ids[2]).Err()
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		t.Skip("the server does not support XSETID")
+	}
+
+	var buf bytes.Buffer
+	_, err = admin.ExportStream("gotestStream1", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = admin.ImportStream(&buf, &redis.ImportOption{
+		Stream:     "gotestStream2",
+		PreserveID: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert
+	{
+		info, err := admin.StreamInfo("gotestStream2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.LastGeneratedID != ids[2] {
+			t.Errorf("expect last-generated-id %s, but got %s", ids[2], info.LastGeneratedID)
+		}
+	}
+}
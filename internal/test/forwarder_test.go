@@ -1,10 +1,13 @@
 package test
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
 )
 
 func TestForwarder(t *testing.T) {
@@ -109,3 +112,704 @@ func TestForwarder(t *testing.T) {
 		}
 	}
 }
+
+func TestForwarderRunner(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := redis.NewForwarder(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Source = &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestForwarder",
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+	}
+	f.Streams = []redis.StreamOffset{
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	}
+	f.Targets = []string{"gotestStream2"}
+	f.Transform = func(stream string, message *redis.XMessage) (map[string]interface{}, bool) {
+		if message.Values["name"] == "nami" {
+			return nil, false
+		}
+		return map[string]interface{}{
+			"name":   message.Values["name"],
+			"source": stream,
+		}, true
+	}
+
+	runner := f.Runner()
+	err = runner.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// produce message
+	{
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	runner.Stop()
+
+	// assert
+	{
+		messages, err := admin.Handle().XRange("gotestStream2", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 2 {
+			t.Fatalf("expect %d messages, but got %d messages", 2, len(messages))
+		}
+		if messages[0].Values["name"] != "luffy" || messages[0].Values["source"] != "gotestStream1" {
+			t.Errorf("unexpected message %v", messages[0].Values)
+		}
+
+		pending, err := admin.Handle().XPending("gotestStream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending.Count != 0 {
+			t.Errorf("expect no pending messages, but got %d messages", pending.Count)
+		}
+	}
+}
+
+func TestForwarderRunner_SeparateSource(t *testing.T) {
+	sourceOpt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+	targetOpt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    1,
+	}
+
+	source, err := redis.NewAdminClient(sourceOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := redis.NewAdminClient(targetOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		source.Handle().Del("gotestStream1", "gotestStream2")
+		target.Handle().Del("gotestStream2")
+		source.Close()
+		target.Close()
+	}()
+
+	// reset
+	{
+		source.Handle().Del("gotestStream1", "gotestStream2")
+		target.Handle().Del("gotestStream2")
+		_, err = source.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := redis.NewForwarder(targetOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Source = &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestForwarder",
+		RedisOption:         sourceOpt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+	}
+	f.Streams = []redis.StreamOffset{
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	}
+	f.Targets = []string{"gotestStream2"}
+
+	runner := f.Runner()
+	err = runner.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// produce message
+	{
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			_, err = source.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	runner.Stop()
+
+	// assert
+	{
+		forwarded, err := target.Handle().XLen("gotestStream2").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if forwarded != 3 {
+			t.Errorf("expect %d messages on the target redis, but got %d messages", 3, forwarded)
+		}
+
+		misplaced, err := source.Handle().XLen("gotestStream2").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if misplaced != 0 {
+			t.Errorf("expect %d messages on the source redis, but got %d messages", 0, misplaced)
+		}
+
+		pending, err := source.Handle().XPending("gotestStream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending.Count != 0 {
+			t.Errorf("expect no pending messages, but got %d messages", pending.Count)
+		}
+	}
+}
+
+func TestForwarderRunner_Mirror(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream2}:mirror")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream2}:mirror")
+	}
+
+	produce := func(names ...string) {
+		for _, name := range names {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	mirror := func(d time.Duration) {
+		f, err := redis.NewForwarder(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.Streams = []redis.StreamOffset{
+			redis.FromStreamZeroOffset("gotestStream1"),
+		}
+		f.Targets = []string{"gotestStream2"}
+		f.Mirror = &redis.MirrorOption{
+			SourceRedisOption: opt,
+			PreserveID:        true,
+			PollingTimeout:    10 * time.Millisecond,
+		}
+
+		runner := f.Runner()
+		err = runner.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(d)
+
+		lags, err := f.MirrorLag()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lags) != 1 || lags[0].CheckpointID != lags[0].SourceLastID {
+			t.Errorf("expect no replication lag, but got %+v", lags[0])
+		}
+		runner.Stop()
+	}
+
+	produce("luffy", "nami")
+	mirror(500 * time.Millisecond)
+	produce("zoro")
+	mirror(500 * time.Millisecond)
+
+	// assert
+	{
+		source, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		target, err := admin.Handle().XRange("gotestStream2", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(target) != len(source) {
+			t.Fatalf("expect %d messages, but got %d messages", len(source), len(target))
+		}
+		for i := range source {
+			if source[i].ID != target[i].ID || source[i].Values["name"] != target[i].Values["name"] {
+				t.Errorf("expect message %v, but got %v", source[i], target[i])
+			}
+		}
+	}
+}
+
+func TestForwarderRunner_MirrorResume(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream2}:mirror")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream2}:mirror")
+	}
+
+	// produce message
+	{
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"name": name,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// a previous run wrote the first message but not the checkpoint
+		source, err := admin.Handle().XRangeN("gotestStream1", "-", "+", 1).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream2", ID: source[0].ID, Values: source[0].Values}).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := redis.NewForwarder(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Streams = []redis.StreamOffset{
+		redis.FromStreamZeroOffset("gotestStream1"),
+	}
+	f.Targets = []string{"gotestStream2"}
+	f.Mirror = &redis.MirrorOption{
+		SourceRedisOption: opt,
+		PreserveID:        true,
+		PollingTimeout:    10 * time.Millisecond,
+	}
+
+	runner := f.Runner()
+	err = runner.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	runner.Stop()
+
+	// assert
+	{
+		select {
+		case err := <-runner.Err():
+			t.Errorf("expect no error, but got %v", err)
+		default:
+		}
+
+		source, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		target, err := admin.Handle().XRange("gotestStream2", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(target) != len(source) {
+			t.Fatalf("expect %d messages, but got %d messages", len(source), len(target))
+		}
+		for i := range source {
+			if source[i].ID != target[i].ID || source[i].Values["name"] != target[i].Values["name"] {
+				t.Errorf("expect message %v, but got %v", source[i], target[i])
+			}
+		}
+	}
+}
+
+func TestForwarderRunner_MirrorError(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream2}:mirror")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream2}:mirror")
+		// not a stream
+		err = admin.Handle().Set("gotestStream2", "luffy", 0).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	{
+		_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+			"name": "luffy",
+		}}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := redis.NewForwarder(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Streams = []redis.StreamOffset{
+		redis.FromStreamZeroOffset("gotestStream1"),
+	}
+	f.Targets = []string{"gotestStream2"}
+	f.Mirror = &redis.MirrorOption{
+		SourceRedisOption: opt,
+		PreserveID:        true,
+		PollingTimeout:    10 * time.Millisecond,
+	}
+
+	runner := f.Runner()
+	err = runner.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer runner.Stop()
+
+	// assert
+	{
+		timeout := time.Duration(redis.DEFAULT_MAX_WORKER_FAILURES+2) * redis.DEFAULT_RETRY_BACKOFF
+		select {
+		case err := <-runner.Err():
+			if err == nil {
+				t.Errorf("expect an error, but got nil")
+			}
+		case <-time.After(timeout):
+			t.Errorf("expect an error in %v, but got none", timeout)
+		}
+	}
+}
+
+func TestForwarderRunner_Router(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	var streams = []string{"gotestStream1", "gotestOrders", "gotestTenant:42", "gotestAudit"}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del(streams...)
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del(streams...)
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var unhandledCnt int = 0
+
+	f, err := redis.NewForwarder(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Source = &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestForwarder",
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		UnhandledMessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			unhandledCnt++
+		},
+	}
+	f.Streams = []redis.StreamOffset{
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	}
+	f.Router = &redis.Router{
+		Rules: []*redis.RouteRule{
+			redis.RouteDrop(func(stream string, message *redis.XMessage) bool {
+				return message.Values["type"] == "debug"
+			}),
+			redis.RouteFieldEquals("type", "order.created", "gotestOrders", "gotestAudit"),
+			redis.RouteFieldPrefix("type", "tenant.", "gotestTenant:${tenant}"),
+		},
+	}
+
+	runner := f.Runner()
+	err = runner.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// produce message
+	{
+		for _, values := range []map[string]interface{}{
+			{"type": "order.created", "id": "1"},
+			{"type": "tenant.updated", "tenant": "42"},
+			{"type": "debug"},
+			{"type": "unknown"},
+			{"type": "tenant.updated"},
+		} {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: values}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	runner.Stop()
+
+	// assert
+	{
+		for stream, expectedMsgCnt := range map[string]int64{
+			"gotestOrders":    1,
+			"gotestAudit":     1,
+			"gotestTenant:42": 1,
+		} {
+			msgCnt, err := admin.Handle().XLen(stream).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msgCnt != expectedMsgCnt {
+				t.Errorf("expect %d messages on %s, but got %d messages", expectedMsgCnt, stream, msgCnt)
+			}
+		}
+
+		var expectedUnhandledCnt int = 2
+		if unhandledCnt != expectedUnhandledCnt {
+			t.Errorf("expect %d unhandled messages, but got %d messages", expectedUnhandledCnt, unhandledCnt)
+		}
+	}
+}
+
+func TestForwarderRunner_RouterWithoutFallback(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	f, err := redis.NewForwarder(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Source = &redis.Consumer{
+		Group: "gotestGroup",
+		Name:  "gotestForwarder",
+	}
+	f.Streams = []redis.StreamOffset{
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	}
+	f.Router = &redis.Router{
+		Rules: []*redis.RouteRule{
+			redis.RouteFieldEquals("type", "order.created", "gotestOrders"),
+		},
+	}
+
+	// unroutable messages would stay pending forever
+	err = f.Runner().Start()
+	if err == nil {
+		t.Errorf("expect an error without DefaultTarget and UnhandledMessageHandler, but got nil")
+	}
+}
+
+func TestForwarderRunner_Merge(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestStream3", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream3}:merge")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1", "gotestStream2", "gotestStream3", redis.CHECKPOINT_KEY_PREFIX+"{gotestStream3}:merge")
+	}
+
+	produce := func(stream, id string) {
+		_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: stream, ID: id, Values: map[string]interface{}{
+			"id": id,
+		}}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merge := func(d time.Duration) {
+		f, err := redis.NewForwarder(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.Streams = []redis.StreamOffset{
+			redis.FromStreamZeroOffset("gotestStream1"),
+			redis.FromStreamZeroOffset("gotestStream2"),
+		}
+		f.Targets = []string{"gotestStream3"}
+		f.Merge = &redis.MergeOption{
+			WatermarkDelay:  10 * time.Millisecond,
+			BatchSize:       2,
+			PollingInterval: 10 * time.Millisecond,
+		}
+
+		runner := f.Runner()
+		err = runner.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(d)
+		runner.Stop()
+	}
+
+	produce("gotestStream1", "1-0")
+	produce("gotestStream2", "2-0")
+	produce("gotestStream2", "3-0")
+	produce("gotestStream1", "4-0")
+	produce("gotestStream1", "5-0")
+	merge(500 * time.Millisecond)
+	produce("gotestStream2", "6-0")
+	produce("gotestStream1", "7-0")
+	merge(500 * time.Millisecond)
+
+	// assert
+	{
+		expected := []struct {
+			stream string
+			id     string
+		}{
+			{"gotestStream1", "1-0"},
+			{"gotestStream2", "2-0"},
+			{"gotestStream2", "3-0"},
+			{"gotestStream1", "4-0"},
+			{"gotestStream1", "5-0"},
+			{"gotestStream2", "6-0"},
+			{"gotestStream1", "7-0"},
+		}
+
+		target, err := admin.Handle().XRange("gotestStream3", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(target) != len(expected) {
+			t.Fatalf("expect %d messages, but got %d messages", len(expected), len(target))
+		}
+		for i, message := range target {
+			if message.Values[redis.FIELD_SOURCE_STREAM] != expected[i].stream ||
+				message.Values[redis.FIELD_SOURCE_ID] != expected[i].id ||
+				message.Values["id"] != expected[i].id {
+				t.Errorf("expect message %s from %s, but got %+v", expected[i].id, expected[i].stream, message.Values)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+type mockRunner struct {
+	name    string
+	stopped *[]string
+	errChan chan error
+}
+
+func (r *mockRunner) Start() error      { return nil }
+func (r *mockRunner) Stop()             { *r.stopped = append(*r.stopped, r.name) }
+func (r *mockRunner) Err() <-chan error { return r.errChan }
+
+func TestHost(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stopped []string
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       10 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+		},
+	}
+
+	host := redis.NewHost(
+		&mockRunner{name: "first", stopped: &stopped},
+		c.Runner(redis.FromStreamNeverDeliveredOffset("gotestStream1")),
+		&mockRunner{name: "last", stopped: &stopped},
+	)
+
+	err = host.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the consumer group disappears; XREADGROUP fails with NOGROUP
+	admin.Handle().Del("gotestStream1")
+
+	// assert
+	{
+		select {
+		case err := <-host.Err():
+			if err == nil {
+				t.Errorf("expect fatal error, but got nil")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expect fatal error, but got nothing")
+		}
+
+		host.Stop()
+		host.Stop()
+
+		if len(stopped) != 2 || stopped[0] != "last" || stopped[1] != "first" {
+			t.Errorf("expect runners stopped in reverse order, but got %v", stopped)
+		}
+	}
+}
+
+func TestHost_Restart(t *testing.T) {
+	var stopped []string
+
+	host := redis.NewHost(
+		&mockRunner{name: "first", stopped: &stopped},
+		&mockRunner{name: "last", stopped: &stopped},
+	)
+
+	for i := 0; i < 100; i++ {
+		err := host.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		host.Stop()
+	}
+
+	// assert
+	{
+		if len(stopped) != 200 {
+			t.Errorf("expect %d stopped runners, but got %d", 200, len(stopped))
+		}
+	}
+}
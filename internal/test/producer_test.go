@@ -109,3 +109,72 @@ func TestProducer(t *testing.T) {
 		}
 	}
 }
+
+func TestProducer_WriteIdempotent(t *testing.T) {
+	p, err := redis.NewProducer(&redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		client := p.Handle()
+		client.Del("gotestStream1", redis.IDEMPOTENCY_KEY_PREFIX+"{gotestStream1}:luffy-order-1")
+
+		p.Close()
+	}()
+
+	// reset
+	{
+		client := p.Handle()
+		client.Del("gotestStream1", redis.IDEMPOTENCY_KEY_PREFIX+"{gotestStream1}:luffy-order-1")
+	}
+
+	// produce message
+	var ids []string
+	for i := 0; i < 3; i++ {
+		reply, err := p.WriteIdempotent("gotestStream1", "luffy-order-1", map[string]interface{}{
+			"name": "luffy",
+			"age":  19,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, reply)
+	}
+	{
+		_, err := p.WriteIdempotent("gotestStream1", "", map[string]interface{}{
+			"name": "nami",
+			"age":  21,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert
+	{
+		if ids[0] != ids[1] || ids[0] != ids[2] {
+			t.Errorf("expect the same ID on duplicated writes, but got %v", ids)
+		}
+
+		client := p.Handle()
+		msgCnt, err := client.XLen("gotestStream1").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 2
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+
+		messages, err := client.XRange("gotestStream1", ids[0], ids[0]).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if messages[0].Values[redis.FIELD_IDEMPOTENCY_KEY] != "luffy-order-1" {
+			t.Errorf("expect idempotency key stored in message, but got %v", messages[0].Values)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
+)
+
+func TestAdminClient_ApplyRetention(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamZeroOffset)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	{
+		for i := 1; i <= 10; i++ {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", ID: fmt.Sprintf("%d-0", i*1000), Values: map[string]interface{}{
+				"seq": i,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// consume 3 messages and ack 2 of them
+	{
+		_, err = admin.Handle().XReadGroup(&goredis.XReadGroupArgs{
+			Group:    "gotestGroup",
+			Consumer: "gotestConsumer",
+			Streams:  []string{"gotestStream1", redis.StreamNeverDeliveredOffset},
+			Count:    3,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = admin.AckPending("gotestStream1", "gotestGroup", "1000-0", "2000-0")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert
+	{
+		result, err := admin.ApplyRetention("gotestStream1", &redis.RetentionSpec{MaxAge: time.Hour}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.MinID != "3000-0" || result.Trimmed != 2 || !result.Limited {
+			t.Errorf("expect trimmed %d before 3000-0 limited by pending entries, but got %+v", 2, result)
+		}
+
+		result, err = admin.ApplyRetention("gotestStream1", &redis.RetentionSpec{MaxLen: 4}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.MinID != "7000-0" || result.Trimmed != 4 || result.Limited {
+			t.Errorf("expect trimmed %d before 7000-0, but got %+v", 4, result)
+		}
+
+		result, err = admin.ApplyRetention("gotestStream1", &redis.RetentionSpec{MaxLen: 100}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.MinID) != 0 || result.Trimmed != 0 {
+			t.Errorf("expect nothing trimmed, but got %+v", result)
+		}
+	}
+}
+
+func TestRetentionRunner(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+	}
+
+	// produce message
+	{
+		for i := 0; i < 10; i++ {
+			_, err = admin.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+				"seq": i,
+			}}).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	runner := &redis.RetentionRunner{
+		RedisOption: opt,
+		Streams: map[string]*redis.RetentionSpec{
+			"gotestStream1": {MaxLen: 3},
+		},
+		Interval: 10 * time.Millisecond,
+	}
+	err = runner.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	runner.Stop()
+
+	// assert
+	{
+		select {
+		case err := <-runner.Err():
+			t.Errorf("expect no error, but got %v", err)
+		default:
+		}
+
+		msgCnt, err := admin.Handle().XLen("gotestStream1").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msgCnt != 3 {
+			t.Errorf("expect %d messages, but got %d messages", 3, msgCnt)
+		}
+	}
+}
+
+func TestRetentionRunner_Error(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		// not a stream
+		err = admin.Handle().Set("gotestStream1", "luffy", 0).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runner := &redis.RetentionRunner{
+		RedisOption: opt,
+		Streams: map[string]*redis.RetentionSpec{
+			"gotestStream1": {MaxLen: 3},
+		},
+		Interval: 10 * time.Millisecond,
+	}
+	err = runner.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer runner.Stop()
+
+	// assert
+	{
+		select {
+		case err := <-runner.Err():
+			if err == nil {
+				t.Errorf("expect an error, but got nil")
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expect an error, but got none")
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+	goredis "github.com/go-redis/redis/v7"
+)
+
+func TestSigning(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keyProvider := &redis.StaticKeyProvider{
+		CurrentKeyID: "v1",
+		Keys: map[string][]byte{
+			"v1": []byte("secret"),
+		},
+	}
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		p.Signing = &redis.SigningOption{
+			KeyProvider: keyProvider,
+		}
+
+		_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+			"name": "luffy",
+			"age":  19,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// tampered message
+		{
+			messages, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := messages[0].Values
+			values["age"] = "99"
+			err = p.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: values}).Err()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// foreign message
+		err = p.Handle().XAdd(&goredis.XAddArgs{Stream: "gotestStream1", Values: map[string]interface{}{
+			"name": "buggy",
+		}}).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		received  []map[string]interface{}
+		unhandled []map[string]interface{}
+	)
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		Middlewares: []redis.MessageMiddlewareProc{
+			redis.VerifySignature(keyProvider),
+		},
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			received = append(received, message.Values)
+		},
+		UnhandledMessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			unhandled = append(unhandled, message.Values)
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if len(received) != 1 {
+			t.Fatalf("expect 1 messages, but got %d messages", len(received))
+		}
+		if received[0]["name"] != "luffy" {
+			t.Errorf("unexpected message %v", received[0])
+		}
+		if len(unhandled) != 2 {
+			t.Errorf("expect 2 unhandled messages, but got %d messages", len(unhandled))
+		}
+	}
+}
+
+func TestSigning_NoKeyProvider(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	p, err := redis.NewProducer(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		p.Handle().Del("gotestStream1")
+		p.Close()
+	}()
+
+	p.Signing = &redis.SigningOption{}
+
+	_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+		"name": "luffy",
+	})
+	if err == nil {
+		t.Errorf("expect an error without KeyProvider, but got nil")
+	}
+}
+
+func TestSigning_ClaimCheck(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("gotestStream1")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("gotestStream1")
+		_, err = admin.CreateConsumerGroupAndStream("gotestStream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keyProvider := &redis.StaticKeyProvider{
+		CurrentKeyID: "v1",
+		Keys: map[string][]byte{
+			"v1": []byte("secret"),
+		},
+	}
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		p.Signing = &redis.SigningOption{
+			KeyProvider: keyProvider,
+		}
+		p.ClaimCheck = &redis.ClaimCheckOption{
+			Threshold: 1,
+			TTL:       time.Minute,
+		}
+
+		for _, name := range []string{"luffy", "nami"} {
+			_, err = p.Write("gotestStream1", redis.StreamAsteriskID, map[string]interface{}{
+				"name": name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// tampered content; the message of luffy now refers to the content of nami
+		{
+			messages, err := admin.Handle().XRange("gotestStream1", "-", "+").Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			luffyKey, _ := messages[0].Values[redis.FIELD_CLAIM_CHECK].(string)
+			namiKey, _ := messages[1].Values[redis.FIELD_CLAIM_CHECK].(string)
+			content, err := admin.Handle().Get(namiKey).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = admin.Handle().Set(luffyKey, content, time.Minute).Err()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer admin.Handle().Del(luffyKey, namiKey)
+		}
+	}
+
+	var (
+		received  []map[string]interface{}
+		unhandled []map[string]interface{}
+	)
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		Middlewares: []redis.MessageMiddlewareProc{
+			redis.VerifySignature(keyProvider),
+		},
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			received = append(received, message.Values)
+		},
+		UnhandledMessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			ctx.Ack(stream, message.ID)
+			unhandled = append(unhandled, message.Values)
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("gotestStream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if len(received) != 1 {
+			t.Fatalf("expect 1 messages, but got %d messages", len(received))
+		}
+		if received[0]["name"] != "nami" {
+			t.Errorf("unexpected message %v", received[0])
+		}
+		if len(unhandled) != 1 {
+			t.Errorf("expect 1 unhandled messages, but got %d messages", len(unhandled))
+		}
+	}
+}
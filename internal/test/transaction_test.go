@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	redis "github.com/bcowtech/lib-redis-stream"
+)
+
+func TestConsumeContext_ForwardAndAck(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("{gotest}Stream1", "{gotest}Stream2", "{gotest}Stream3")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("{gotest}Stream1", "{gotest}Stream2", "{gotest}Stream3")
+		_, err = admin.CreateConsumerGroupAndStream("{gotest}Stream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		for _, name := range []string{"luffy", "nami"} {
+			_, err = p.Write("{gotest}Stream1", redis.StreamAsteriskID, map[string]interface{}{
+				"name": name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var notPendingCnt int = 0
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			if message.Values["name"] == "luffy" {
+				_, err := ctx.ForwardAndAck("{gotest}Stream2", message.Values)
+				if err != nil {
+					t.Error(err)
+				}
+			} else {
+				_, err := ctx.ForwardAndAckAll([]string{"{gotest}Stream2", "{gotest}Stream3"}, message.Values)
+				if err != nil {
+					t.Error(err)
+				}
+			}
+
+			// the message has been acknowledged
+			_, err := ctx.ForwardAndAck("{gotest}Stream2", message.Values)
+			if err == redis.ErrMessageNotPending {
+				notPendingCnt++
+			}
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("{gotest}Stream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if notPendingCnt != 2 {
+			t.Errorf("expect %d ErrMessageNotPending, but got %d", 2, notPendingCnt)
+		}
+
+		for stream, expectedMsgCnt := range map[string]int64{
+			"{gotest}Stream2": 2,
+			"{gotest}Stream3": 1,
+		} {
+			msgCnt, err := admin.Handle().XLen(stream).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msgCnt != expectedMsgCnt {
+				t.Errorf("expect %d messages on %s, but got %d messages", expectedMsgCnt, stream, msgCnt)
+			}
+		}
+	}
+}
+
+func TestConsumeContext_AckAndWrite_WrongType(t *testing.T) {
+	opt := &redis.UniversalOptions{
+		Addrs: []string{os.Getenv("REDIS_SERVER")},
+		DB:    0,
+	}
+
+	admin, err := redis.NewAdminClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		admin.Handle().Del("{gotest}Stream1", "{gotest}Stream2", "{gotest}Stream3")
+		admin.Close()
+	}()
+
+	// reset
+	{
+		admin.Handle().Del("{gotest}Stream1", "{gotest}Stream2", "{gotest}Stream3")
+		_, err = admin.CreateConsumerGroupAndStream("{gotest}Stream1", "gotestGroup", redis.StreamLastDeliveredID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// not a stream
+		err = admin.Handle().Set("{gotest}Stream3", "luffy", 0).Err()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// produce message
+	{
+		p, err := redis.NewProducer(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		_, err = p.Write("{gotest}Stream1", redis.StreamAsteriskID, map[string]interface{}{
+			"name": "luffy",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var errCnt int = 0
+
+	c := &redis.Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    30 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    2,
+		ClaimOccurrenceRate: 2,
+		MessageHandler: func(ctx *redis.ConsumeContext, stream string, message *redis.XMessage) {
+			_, err := ctx.ForwardAndAckAll([]string{"{gotest}Stream2", "{gotest}Stream3"}, message.Values)
+			if err != nil {
+				errCnt++
+			}
+		},
+	}
+
+	err = c.Subscribe(
+		redis.FromStreamNeverDeliveredOffset("{gotest}Stream1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		if errCnt == 0 {
+			t.Errorf("expect errors on the output which is not a stream, but got none")
+		}
+
+		msgCnt, err := admin.Handle().Exists("{gotest}Stream2").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msgCnt != 0 {
+			t.Errorf("expect nothing written to %s, but it exists", "{gotest}Stream2")
+		}
+
+		pending, err := admin.Handle().XPending("{gotest}Stream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pending.Count != 1 {
+			t.Errorf("expect %d pending messages, but got %d", 1, pending.Count)
+		}
+	}
+}
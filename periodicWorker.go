@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// periodicWorker calls a proc every interval in background; it drives the
+// Runners which maintain streams, e.g. ConsumerJanitor and RetentionRunner.
+// A failed first round, or DEFAULT_MAX_WORKER_FAILURES consecutive failed
+// rounds, stops the worker and is reported by Err; other failures are
+// logged and retried on the next round.
+type periodicWorker struct {
+	stopChan chan bool
+	errChan  chan error
+	wg       sync.WaitGroup
+}
+
+func (w *periodicWorker) start(interval time.Duration, proc func() error) {
+	var fail func(err error)
+	w.errChan, fail = runnerErrorChan()
+	w.stopChan = make(chan bool)
+
+	w.wg.Add(1)
+	go func(stop chan bool) {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for round, failures := 1, 0; ; round++ {
+			err := proc()
+			if err == nil {
+				failures = 0
+			} else {
+				failures++
+				if round == 1 {
+					fail(err)
+					return
+				}
+				if failures >= DEFAULT_MAX_WORKER_FAILURES {
+					fail(fmt.Errorf("gave up after %d consecutive failures: %v", failures, err))
+					return
+				}
+				logger.Printf("%% Warning: %v\n", err)
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}(w.stopChan)
+}
+
+// stop stops the worker and reports whether it was running.
+func (w *periodicWorker) stop() bool {
+	if w.stopChan == nil {
+		return false
+	}
+	close(w.stopChan)
+	w.wg.Wait()
+	w.stopChan = nil
+	return true
+}
+
+func (w *periodicWorker) err() <-chan error {
+	return w.errChan
+}
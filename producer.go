@@ -3,12 +3,20 @@ package redis
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/bcowtech/lib-redis-stream/internal"
 	redis "github.com/go-redis/redis/v7"
 )
 
 type Producer struct {
+	Compression *CompressionOption
+	Encryption  *EncryptionOption
+	ClaimCheck  *ClaimCheckOption
+	Signing     *SigningOption
+
+	IdempotencyWindow time.Duration // WriteIdempotent 記錄 idempotency key 的時間, 預設為 DEFAULT_IDEMPOTENCY_WINDOW
+
 	handle redis.UniversalClient
 
 	wg       sync.WaitGroup
@@ -39,13 +47,21 @@ func (p *Producer) Write(stream string, id string, content map[string]interface{
 	p.wg.Add(1)
 	defer p.wg.Done()
 
+	values, blobKey, err := p.encodeContent(stream, content, "")
+	if err != nil {
+		return "", err
+	}
+
 	reply, err := p.handle.XAdd(&redis.XAddArgs{
 		Stream: stream,
 		ID:     id,
-		Values: content,
+		Values: values,
 	}).Result()
 	if err != nil {
 		if err != redis.Nil {
+			if len(blobKey) > 0 {
+				deleteBlobs(p.blobStore(), blobKey)
+			}
 			return "", err
 		}
 	}
@@ -66,6 +82,65 @@ func (p *Producer) Close() {
 	p.handle.Close()
 }
 
+func (p *Producer) encodeContent(stream string, content map[string]interface{}, idempotencyKey string) (map[string]interface{}, string, error) {
+	if p.Compression == nil && p.Encryption == nil && p.ClaimCheck == nil && p.Signing == nil {
+		if len(idempotencyKey) > 0 {
+			values := make(map[string]interface{}, len(content)+1)
+			for k, v := range content {
+				values[k] = v
+			}
+			values[FIELD_IDEMPOTENCY_KEY] = idempotencyKey
+			return values, "", nil
+		}
+		return content, "", nil
+	}
+
+	fields, err := internal.NormalizeValues(content)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if p.Compression != nil {
+		fields, err = p.Compression.compress(fields)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if p.Encryption != nil {
+		fields, err = p.Encryption.encrypt(fields)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	var blobKey string
+	if p.ClaimCheck != nil {
+		fields, blobKey, err = p.ClaimCheck.checkIn(p.blobStore(), stream, fields)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if len(idempotencyKey) > 0 {
+		fields[FIELD_IDEMPOTENCY_KEY] = idempotencyKey
+	}
+
+	if p.Signing != nil {
+		fields, err = p.Signing.sign(fields)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return internal.ToValues(fields), blobKey, nil
+}
+
+func (p *Producer) blobStore() BlobStore {
+	if p.ClaimCheck.Store != nil {
+		return p.ClaimCheck.Store
+	}
+	return NewRedisBlobStore(p.handle)
+}
+
 func (p *Producer) init(opt *UniversalOptions) error {
 	client, err := internal.CreateRedisUniversalClient(opt)
 	if err != nil {
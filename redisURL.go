@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseRedisURL parses a redis URL into UniversalOptions. Supported forms:
+//
+//	redis://[[user]:password@]host[:port][,host[:port]...][/db][?option=value]
+//	rediss://...   same as redis:// with TLS
+//	redis-sentinel://[[user]:password@]host[:port][,...]/master[/db][?option=value]
+//
+// Several hosts of redis:// or rediss:// make a cluster client. Supported
+// options are db, master_name, pool_size, min_idle_conns, max_retries,
+// dial_timeout, read_timeout, write_timeout, pool_timeout, idle_timeout
+// and, for rediss://, skip_verify.
+func ParseRedisURL(rawurl string) (*UniversalOptions, error) {
+	// net/url cannot parse a host list; take it out before parsing
+	rawurl, hosts := splitRedisURLHosts(rawurl)
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		opt         = &UniversalOptions{}
+		defaultPort = DEFAULT_REDIS_PORT
+		path        = strings.Trim(u.Path, "/")
+		segments    []string
+	)
+	if len(path) > 0 {
+		segments = strings.Split(path, "/")
+	}
+
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		// the server name is taken from the address of each node on dialing
+		opt.TLSConfig = &tls.Config{}
+	case "redis-sentinel":
+		defaultPort = DEFAULT_SENTINEL_PORT
+		if len(segments) > 0 {
+			opt.MasterName = segments[0]
+			segments = segments[1:]
+		}
+	default:
+		return nil, fmt.Errorf("invalid redis URL scheme %q", u.Scheme)
+	}
+
+	for _, host := range strings.Split(hosts, ",") {
+		if len(host) == 0 {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), defaultPort)
+		}
+		opt.Addrs = append(opt.Addrs, host)
+	}
+	if len(opt.Addrs) == 0 {
+		return nil, fmt.Errorf("no host is specified in redis URL")
+	}
+
+	if u.User != nil {
+		opt.Username = u.User.Username()
+		opt.Password, _ = u.User.Password()
+	}
+
+	switch len(segments) {
+	case 0:
+	case 1:
+		opt.DB, err = strconv.Atoi(segments[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid database %q in redis URL", segments[0])
+		}
+	default:
+		return nil, fmt.Errorf("invalid path %q in redis URL", u.Path)
+	}
+
+	err = parseRedisURLQuery(opt, u.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opt.Addrs) > 1 && len(opt.MasterName) == 0 && opt.DB != 0 {
+		return nil, fmt.Errorf("redis cluster does not support database %d", opt.DB)
+	}
+	if u.Scheme == "redis-sentinel" && len(opt.MasterName) == 0 {
+		return nil, fmt.Errorf("no master name is specified in redis-sentinel URL")
+	}
+	return opt, nil
+}
+
+// splitRedisURLHosts replaces the hosts of rawurl with a placeholder and
+// returns them.
+func splitRedisURLHosts(rawurl string) (string, string) {
+	i := strings.Index(rawurl, "://")
+	if i < 0 {
+		return rawurl, ""
+	}
+	var (
+		start     = i + 3
+		end       = len(rawurl)
+		authority string
+	)
+	if j := strings.IndexAny(rawurl[start:], "/?#"); j >= 0 {
+		end = start + j
+	}
+	authority = rawurl[start:end]
+	if j := strings.LastIndexByte(authority, '@'); j >= 0 {
+		start += j + 1
+	}
+	return rawurl[:start] + "localhost" + rawurl[end:], rawurl[start:end]
+}
+
+func parseRedisURLQuery(opt *UniversalOptions, query url.Values) error {
+	for name, values := range query {
+		var (
+			value = values[len(values)-1]
+			err   error
+		)
+
+		switch name {
+		case "db":
+			opt.DB, err = strconv.Atoi(value)
+		case "master_name":
+			opt.MasterName = value
+		case "pool_size":
+			opt.PoolSize, err = strconv.Atoi(value)
+		case "min_idle_conns":
+			opt.MinIdleConns, err = strconv.Atoi(value)
+		case "max_retries":
+			opt.MaxRetries, err = strconv.Atoi(value)
+		case "dial_timeout":
+			opt.DialTimeout, err = time.ParseDuration(value)
+		case "read_timeout":
+			opt.ReadTimeout, err = time.ParseDuration(value)
+		case "write_timeout":
+			opt.WriteTimeout, err = time.ParseDuration(value)
+		case "pool_timeout":
+			opt.PoolTimeout, err = time.ParseDuration(value)
+		case "idle_timeout":
+			opt.IdleTimeout, err = time.ParseDuration(value)
+		case "skip_verify":
+			if opt.TLSConfig == nil {
+				return fmt.Errorf("the option skip_verify requires rediss:// URL")
+			}
+			opt.TLSConfig.InsecureSkipVerify, err = strconv.ParseBool(value)
+		default:
+			return fmt.Errorf("unknown option %q in redis URL", name)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid option %s=%q in redis URL", name, value)
+		}
+	}
+	return nil
+}
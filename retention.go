@@ -0,0 +1,179 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+type RetentionResult struct {
+	Stream  string
+	MinID   string // 保留的最小 ID; 為空表示不需要刪除
+	Trimmed int64  // 刪除的訊息數
+	Limited bool   // 安全模式下因為尚未消費的訊息而少刪除
+}
+
+// ApplyRetention trims the entries of stream beyond retention with XTRIM
+// MINID, which requires redis 6.2+. MaxLen is converted to the ID of the
+// oldest entry to keep, so both limits are applied by one XTRIM.
+//
+// In safe mode, entries which are not delivered to every consumer group,
+// or are still pending, are never trimmed.
+func (c *AdminClient) ApplyRetention(stream string, retention *RetentionSpec, safe bool) (*RetentionResult, error) {
+	var (
+		result = &RetentionResult{Stream: stream}
+		minID  *internal.StreamID
+	)
+	keep := func(id internal.StreamID) {
+		if minID == nil || id.Compare(*minID) > 0 {
+			minID = &id
+		}
+	}
+
+	if retention.MaxAge > 0 {
+		keep(internal.StreamIDFromTime(time.Now().Add(-retention.MaxAge)))
+	}
+	if retention.MaxLen > 0 {
+		messages, err := c.handle.XRevRangeN(stream, "+", "-", retention.MaxLen).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return nil, err
+			}
+		}
+		if int64(len(messages)) == retention.MaxLen {
+			id, err := internal.ParseStreamID(messages[len(messages)-1].ID)
+			if err != nil {
+				return nil, err
+			}
+			keep(id)
+		}
+	}
+	if minID == nil {
+		return result, nil
+	}
+
+	if safe {
+		floor, err := c.unconsumedFloor(stream)
+		if err != nil {
+			return nil, err
+		}
+		if floor != nil && floor.Compare(*minID) < 0 {
+			minID = floor
+			result.Limited = true
+		}
+	}
+
+	result.MinID = minID.String()
+	trimmed, err := c.handle.Do("XTRIM", stream, "MINID", result.MinID).Int64()
+	if err != nil {
+		return nil, err
+	}
+	result.Trimmed = trimmed
+	return result, nil
+}
+
+// unconsumedFloor returns the smallest ID which is not delivered to, or
+// still pending in, any consumer group of stream; nil if there is no group.
+func (c *AdminClient) unconsumedFloor(stream string) (*internal.StreamID, error) {
+	groups, err := c.GroupsInfo(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	var floor *internal.StreamID
+	lower := func(id internal.StreamID) {
+		if floor == nil || id.Compare(*floor) < 0 {
+			floor = &id
+		}
+	}
+	for _, g := range groups {
+		lastDeliveredID, err := internal.ParseStreamID(g.LastDeliveredID)
+		if err != nil {
+			return nil, err
+		}
+		lower(lastDeliveredID.Next())
+
+		if g.Pending > 0 {
+			pending, err := c.handle.XPending(stream, g.Name).Result()
+			if err != nil {
+				if err != redis.Nil {
+					return nil, err
+				}
+			}
+			if pending != nil && len(pending.Lower) > 0 {
+				id, err := internal.ParseStreamID(pending.Lower)
+				if err != nil {
+					return nil, err
+				}
+				lower(id)
+			}
+		}
+	}
+	return floor, nil
+}
+
+// RetentionRunner applies the retention of Streams periodically. See
+// AdminClient.ApplyRetention.
+type RetentionRunner struct {
+	RedisOption *UniversalOptions
+	Streams     map[string]*RetentionSpec
+	Safe        bool          // 不刪除尚未消費的訊息
+	Interval    time.Duration // 執行間隔, 預設為 DEFAULT_RETENTION_INTERVAL
+
+	admin  *AdminClient
+	worker periodicWorker
+}
+
+var _ Runner = new(RetentionRunner)
+
+func (r *RetentionRunner) Start() error {
+	for stream, retention := range r.Streams {
+		if retention == nil || (retention.MaxAge <= 0 && retention.MaxLen <= 0) {
+			return fmt.Errorf("the retention of %s is not specified", stream)
+		}
+	}
+
+	admin, err := NewAdminClient(r.RedisOption)
+	if err != nil {
+		return err
+	}
+	r.admin = admin
+
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DEFAULT_RETENTION_INTERVAL
+	}
+	r.worker.start(interval, r.apply)
+	return nil
+}
+
+func (r *RetentionRunner) Stop() {
+	if r.worker.stop() {
+		r.admin.Close()
+	}
+}
+
+// Err reports the trimming error which stops the RetentionRunner; see
+// periodicWorker.
+func (r *RetentionRunner) Err() <-chan error {
+	return r.worker.err()
+}
+
+// apply applies the retention of every stream; it fails if any stream
+// fails.
+func (r *RetentionRunner) apply() error {
+	var lastErr error
+	for stream, retention := range r.Streams {
+		result, err := r.admin.ApplyRetention(stream, retention, r.Safe)
+		if err != nil {
+			lastErr = fmt.Errorf("cannot apply retention to %s: %v", stream, err)
+			continue
+		}
+		if result.Limited {
+			logger.Printf("%% Warning: retention of %s is limited to %s by unconsumed entries\n", stream, result.MinID)
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	routeTargetFieldPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+)
+
+// Router chooses the target streams of a forwarded message. Rules are
+// evaluated in order and the first matched rule wins; messages matching no
+// rule go to DefaultTarget, or to the UnhandledMessageHandler of the
+// Forwarder.Source when DefaultTarget is empty; one of them is required.
+//
+// Targets may refer to message fields with ${field}, e.g. "tenant:${id}".
+type Router struct {
+	Rules         []*RouteRule
+	DefaultTarget string
+}
+
+type RouteRule struct {
+	Match   RouteMatchProc
+	Targets []string
+	Drop    bool // 符合時丟棄訊息 (Ack 但不轉送)
+}
+
+func RouteFieldEquals(field, value string, targets ...string) *RouteRule {
+	return &RouteRule{
+		Match: func(stream string, message *XMessage) bool {
+			v, ok := message.Values[field].(string)
+			return ok && v == value
+		},
+		Targets: targets,
+	}
+}
+
+func RouteFieldPrefix(field, prefix string, targets ...string) *RouteRule {
+	return &RouteRule{
+		Match: func(stream string, message *XMessage) bool {
+			v, ok := message.Values[field].(string)
+			return ok && strings.HasPrefix(v, prefix)
+		},
+		Targets: targets,
+	}
+}
+
+func RouteFieldRegexp(field string, pattern *regexp.Regexp, targets ...string) *RouteRule {
+	return &RouteRule{
+		Match: func(stream string, message *XMessage) bool {
+			v, ok := message.Values[field].(string)
+			return ok && pattern.MatchString(v)
+		},
+		Targets: targets,
+	}
+}
+
+func RoutePredicate(match RouteMatchProc, targets ...string) *RouteRule {
+	return &RouteRule{
+		Match:   match,
+		Targets: targets,
+	}
+}
+
+func RouteDrop(match RouteMatchProc) *RouteRule {
+	return &RouteRule{
+		Match: match,
+		Drop:  true,
+	}
+}
+
+// route returns the target streams of message. The message is dropped if
+// drop is true, and it is unroutable if both are empty.
+func (r *Router) route(stream string, message *XMessage) (targets []string, drop bool) {
+	for _, rule := range r.Rules {
+		if !rule.Match(stream, message) {
+			continue
+		}
+		if rule.Drop {
+			return nil, true
+		}
+		return expandRouteTargets(rule.Targets, message)
+	}
+
+	if len(r.DefaultTarget) > 0 {
+		return expandRouteTargets([]string{r.DefaultTarget}, message)
+	}
+	return nil, false
+}
+
+func expandRouteTargets(targets []string, message *XMessage) ([]string, bool) {
+	var result = make([]string, 0, len(targets))
+	for _, target := range targets {
+		var missing bool
+		expanded := routeTargetFieldPattern.ReplaceAllStringFunc(target, func(s string) string {
+			field := s[2 : len(s)-1]
+			v, ok := message.Values[field].(string)
+			if !ok || len(v) == 0 {
+				missing = true
+			}
+			return v
+		})
+		// a target with missing fields is not routable
+		if missing {
+			return nil, false
+		}
+		result = append(result, expanded)
+	}
+	return result, false
+}
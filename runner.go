@@ -0,0 +1,26 @@
+package redis
+
+// Runner is a long-running service which can be hosted by Host.
+type Runner interface {
+	Start() error
+	Stop()
+	// Err reports the fatal error which stops the Runner unexpectedly.
+	Err() <-chan error
+}
+
+var (
+	_ Runner = new(ConsumerRunner)
+	_ Runner = new(ForwarderRunner)
+)
+
+// runnerErrorChan creates the channel of Runner.Err. Only the first fatal
+// error is kept; the others are dropped.
+func runnerErrorChan() (chan error, func(err error)) {
+	var ch = make(chan error, 1)
+	return ch, func(err error) {
+		select {
+		case ch <- err:
+		default:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bcowtech/lib-redis-stream/internal"
+	redis "github.com/go-redis/redis/v7"
+)
+
+var (
+	ErrMessageNotPending = errors.New("the message is not pending; it might be acknowledged already")
+)
+
+type OutgoingMessage struct {
+	Stream string
+	Values map[string]interface{}
+}
+
+// CrossSlotError reports the keys of an atomic operation which are located
+// in different redis cluster slots.
+type CrossSlotError struct {
+	Keys []string
+}
+
+func (e *CrossSlotError) Error() string {
+	return fmt.Sprintf("keys %s are not in the same cluster slot; "+
+		"use the same hash tag, e.g. {orders}:created and {orders}:shipped, to write them atomically",
+		strings.Join(e.Keys, ", "))
+}
+
+// ForwardAndAck writes content to targetStream and acknowledges the message
+// being handled atomically. See AckAndWrite.
+func (c *ConsumeContext) ForwardAndAck(targetStream string, content map[string]interface{}) (string, error) {
+	ids, err := c.AckAndWrite(&OutgoingMessage{
+		Stream: targetStream,
+		Values: content,
+	})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// ForwardAndAckAll writes content to every stream of targetStreams and
+// acknowledges the message being handled atomically. See AckAndWrite.
+func (c *ConsumeContext) ForwardAndAckAll(targetStreams []string, content map[string]interface{}) ([]string, error) {
+	outputs := make([]*OutgoingMessage, 0, len(targetStreams))
+	for _, stream := range targetStreams {
+		outputs = append(outputs, &OutgoingMessage{
+			Stream: stream,
+			Values: content,
+		})
+	}
+	return c.AckAndWrite(outputs...)
+}
+
+// AckAndWrite writes outputs, acknowledges the message being handled and,
+// if Consumer.Deduplication is set, records it as processed; all in one
+// lua script. It returns the IDs of the outputs, or ErrMessageNotPending
+// without writing anything if the message has been acknowledged already.
+// If an output key is not a stream, the error is returned and nothing is
+// written or acknowledged.
+//
+// On redis cluster all the streams must be located in the same slot,
+// otherwise a *CrossSlotError is returned.
+func (c *ConsumeContext) AckAndWrite(outputs ...*OutgoingMessage) ([]string, error) {
+	if c.message == nil {
+		return nil, errors.New("no message is being handled by the ConsumeContext")
+	}
+
+	var (
+		consumer = c.consumer
+		keys     = make([]string, 0, len(outputs)+2)
+		args     = make([]interface{}, 0, 3+len(outputs)*8)
+		window   time.Duration
+	)
+
+	keys = append(keys, c.stream)
+	for _, output := range outputs {
+		if len(output.Values) == 0 {
+			return nil, errors.New("the output message to " + output.Stream + " is empty")
+		}
+		keys = append(keys, output.Stream)
+	}
+	if err := checkSameSlot(c.Handle(), keys); err != nil {
+		return nil, err
+	}
+	if consumer.Deduplication != nil && len(c.processedKey) > 0 {
+		window = consumer.Deduplication.window()
+		keys = append(keys, c.processedKey)
+	}
+
+	args = append(args, consumer.Group, c.message.ID, window.Milliseconds())
+	for _, output := range outputs {
+		args = append(args, len(output.Values))
+		for k, v := range output.Values {
+			args = append(args, k, v)
+		}
+	}
+
+	reply, err := internal.AckAndWriteScript.Run(c.Handle(), keys, args...).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrMessageNotPending
+		}
+		return nil, err
+	}
+
+	if consumer.ReleaseBlobOnAck {
+		c.releaseBlobs(c.claimCheckKeys(c.stream, c.message.ID))
+	}
+
+	result, _ := reply.([]interface{})
+	ids := make([]string, 0, len(result))
+	for _, v := range result {
+		id, _ := v.(string)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// checkSameSlot verifies keys can be used in one lua script. It only
+// matters to redis cluster; other clients keep all keys on one node.
+func checkSameSlot(client UniversalClient, keys []string) error {
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		return nil
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	slot := internal.HashSlot(keys[0])
+	for _, key := range keys[1:] {
+		if internal.HashSlot(key) != slot {
+			return &CrossSlotError{Keys: keys}
+		}
+	}
+	return nil
+}